@@ -0,0 +1,703 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: api/adapter/v1/adapter.proto
+
+package adapterv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Health_Status int32
+
+const (
+	Health_STATUS_UNSPECIFIED Health_Status = 0
+	// SERVING the publisher accepts items
+	Health_STATUS_SERVING Health_Status = 1
+	// NOT_SERVING the publisher is shutting down or lost the leadership, the stream is closed
+	Health_STATUS_NOT_SERVING Health_Status = 2
+)
+
+// Enum value maps for Health_Status.
+var (
+	Health_Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_SERVING",
+		2: "STATUS_NOT_SERVING",
+	}
+	Health_Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_SERVING":     1,
+		"STATUS_NOT_SERVING": 2,
+	}
+)
+
+func (x Health_Status) Enum() *Health_Status {
+	p := new(Health_Status)
+	*p = x
+	return p
+}
+
+func (x Health_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Health_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_adapter_v1_adapter_proto_enumTypes[0].Descriptor()
+}
+
+func (Health_Status) Type() protoreflect.EnumType {
+	return &file_api_adapter_v1_adapter_proto_enumTypes[0]
+}
+
+func (x Health_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Health_Status.Descriptor instead.
+func (Health_Status) EnumDescriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{6, 0}
+}
+
+// AdapterMessage is sent by the adapter
+type AdapterMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*AdapterMessage_Hello
+	//	*AdapterMessage_Item
+	Message       isAdapterMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdapterMessage) Reset() {
+	*x = AdapterMessage{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdapterMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdapterMessage) ProtoMessage() {}
+
+func (x *AdapterMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdapterMessage.ProtoReflect.Descriptor instead.
+func (*AdapterMessage) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AdapterMessage) GetMessage() isAdapterMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *AdapterMessage) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Message.(*AdapterMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *AdapterMessage) GetItem() *Item {
+	if x != nil {
+		if x, ok := x.Message.(*AdapterMessage_Item); ok {
+			return x.Item
+		}
+	}
+	return nil
+}
+
+type isAdapterMessage_Message interface {
+	isAdapterMessage_Message()
+}
+
+type AdapterMessage_Hello struct {
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type AdapterMessage_Item struct {
+	Item *Item `protobuf:"bytes,2,opt,name=item,proto3,oneof"`
+}
+
+func (*AdapterMessage_Hello) isAdapterMessage_Message() {}
+
+func (*AdapterMessage_Item) isAdapterMessage_Message() {}
+
+// Hello identifies the adapter
+type Hello struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name of the adapter, a DNS-1123 label used as the adapter in the metrics
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// version of the adapter
+	Version       string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Hello) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+// Item is a result to be published on the PolicyReport of a pod or namespace
+type Item struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// namespace of the result
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// pod the result belongs to, the result is published on the report of the namespace if empty
+	Pod           string  `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Result        *Result `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Item) Reset() {
+	*x = Item{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Item) ProtoMessage() {}
+
+func (x *Item) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Item.ProtoReflect.Descriptor instead.
+func (*Item) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Item) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Item) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *Item) GetResult() *Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+// Result is the PolicyReportResult of an item
+type Result struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Policy   string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Rule     string                 `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	Message  string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Category string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	// severity is one of critical, high, medium, low or info
+	Severity string `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	// result is one of pass, fail, warn, error or skip
+	Result string `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"`
+	// scored is derived from the result if not set, warn and skip results are not scored
+	Scored *bool `protobuf:"varint,7,opt,name=scored,proto3,oneof" json:"scored,omitempty"`
+	// source of the result, the adapter name is used if empty
+	Source string `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"`
+	// timestamp of the result, the receive time is used if not set
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Properties    map[string]string      `protobuf:"bytes,10,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Result) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *Result) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Result) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Result) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Result) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Result) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *Result) GetScored() bool {
+	if x != nil && x.Scored != nil {
+		return *x.Scored
+	}
+	return false
+}
+
+func (x *Result) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Result) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Result) GetProperties() map[string]string {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+// PublisherMessage is sent by the publisher
+type PublisherMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*PublisherMessage_Config
+	//	*PublisherMessage_Health
+	Message       isPublisherMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublisherMessage) Reset() {
+	*x = PublisherMessage{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublisherMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublisherMessage) ProtoMessage() {}
+
+func (x *PublisherMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublisherMessage.ProtoReflect.Descriptor instead.
+func (*PublisherMessage) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PublisherMessage) GetMessage() isPublisherMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *PublisherMessage) GetConfig() *Config {
+	if x != nil {
+		if x, ok := x.Message.(*PublisherMessage_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *PublisherMessage) GetHealth() *Health {
+	if x != nil {
+		if x, ok := x.Message.(*PublisherMessage_Health); ok {
+			return x.Health
+		}
+	}
+	return nil
+}
+
+type isPublisherMessage_Message interface {
+	isPublisherMessage_Message()
+}
+
+type PublisherMessage_Config struct {
+	Config *Config `protobuf:"bytes,1,opt,name=config,proto3,oneof"`
+}
+
+type PublisherMessage_Health struct {
+	Health *Health `protobuf:"bytes,2,opt,name=health,proto3,oneof"`
+}
+
+func (*PublisherMessage_Config) isPublisherMessage_Message() {}
+
+func (*PublisherMessage_Health) isPublisherMessage_Message() {}
+
+// Config is the configuration of the adapter, sent after the Hello
+type Config struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// settings of the adapter defined by the publisher
+	Settings      map[string]string `protobuf:"bytes,1,rep,name=settings,proto3" json:"settings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Config) GetSettings() map[string]string {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+// Health is the status of the publisher
+type Health struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        Health_Status          `protobuf:"varint,1,opt,name=status,proto3,enum=policyreportpublisher.adapter.v1.Health_Status" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Health) Reset() {
+	*x = Health{}
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Health) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Health) ProtoMessage() {}
+
+func (x *Health) ProtoReflect() protoreflect.Message {
+	mi := &file_api_adapter_v1_adapter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Health.ProtoReflect.Descriptor instead.
+func (*Health) Descriptor() ([]byte, []int) {
+	return file_api_adapter_v1_adapter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Health) GetStatus() Health_Status {
+	if x != nil {
+		return x.Status
+	}
+	return Health_STATUS_UNSPECIFIED
+}
+
+var File_api_adapter_v1_adapter_proto protoreflect.FileDescriptor
+
+const file_api_adapter_v1_adapter_proto_rawDesc = "" +
+	"\n" +
+	"\x1capi/adapter/v1/adapter.proto\x12 policyreportpublisher.adapter.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9a\x01\n" +
+	"\x0eAdapterMessage\x12?\n" +
+	"\x05hello\x18\x01 \x01(\v2'.policyreportpublisher.adapter.v1.HelloH\x00R\x05hello\x12<\n" +
+	"\x04item\x18\x02 \x01(\v2&.policyreportpublisher.adapter.v1.ItemH\x00R\x04itemB\t\n" +
+	"\amessage\"5\n" +
+	"\x05Hello\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\"x\n" +
+	"\x04Item\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x10\n" +
+	"\x03pod\x18\x02 \x01(\tR\x03pod\x12@\n" +
+	"\x06result\x18\x03 \x01(\v2(.policyreportpublisher.adapter.v1.ResultR\x06result\"\xb1\x03\n" +
+	"\x06Result\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x12\n" +
+	"\x04rule\x18\x02 \x01(\tR\x04rule\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06result\x18\x06 \x01(\tR\x06result\x12\x1b\n" +
+	"\x06scored\x18\a \x01(\bH\x00R\x06scored\x88\x01\x01\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x128\n" +
+	"\ttimestamp\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12X\n" +
+	"\n" +
+	"properties\x18\n" +
+	" \x03(\v28.policyreportpublisher.adapter.v1.Result.PropertiesEntryR\n" +
+	"properties\x1a=\n" +
+	"\x0fPropertiesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
+	"\a_scored\"\xa5\x01\n" +
+	"\x10PublisherMessage\x12B\n" +
+	"\x06config\x18\x01 \x01(\v2(.policyreportpublisher.adapter.v1.ConfigH\x00R\x06config\x12B\n" +
+	"\x06health\x18\x02 \x01(\v2(.policyreportpublisher.adapter.v1.HealthH\x00R\x06healthB\t\n" +
+	"\amessage\"\x99\x01\n" +
+	"\x06Config\x12R\n" +
+	"\bsettings\x18\x01 \x03(\v26.policyreportpublisher.adapter.v1.Config.SettingsEntryR\bsettings\x1a;\n" +
+	"\rSettingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9f\x01\n" +
+	"\x06Health\x12G\n" +
+	"\x06status\x18\x01 \x01(\x0e2/.policyreportpublisher.adapter.v1.Health.StatusR\x06status\"L\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSTATUS_SERVING\x10\x01\x12\x16\n" +
+	"\x12STATUS_NOT_SERVING\x10\x022\x85\x01\n" +
+	"\x0eAdapterService\x12s\n" +
+	"\aConnect\x120.policyreportpublisher.adapter.v1.AdapterMessage\x1a2.policyreportpublisher.adapter.v1.PublisherMessage(\x010\x01BDZBgithub.com/bakito/policy-report-publisher/api/adapter/v1;adapterv1b\x06proto3"
+
+var (
+	file_api_adapter_v1_adapter_proto_rawDescOnce sync.Once
+	file_api_adapter_v1_adapter_proto_rawDescData []byte
+)
+
+func file_api_adapter_v1_adapter_proto_rawDescGZIP() []byte {
+	file_api_adapter_v1_adapter_proto_rawDescOnce.Do(func() {
+		file_api_adapter_v1_adapter_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_adapter_v1_adapter_proto_rawDesc), len(file_api_adapter_v1_adapter_proto_rawDesc)))
+	})
+	return file_api_adapter_v1_adapter_proto_rawDescData
+}
+
+var file_api_adapter_v1_adapter_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_adapter_v1_adapter_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_adapter_v1_adapter_proto_goTypes = []any{
+	(Health_Status)(0),            // 0: policyreportpublisher.adapter.v1.Health.Status
+	(*AdapterMessage)(nil),        // 1: policyreportpublisher.adapter.v1.AdapterMessage
+	(*Hello)(nil),                 // 2: policyreportpublisher.adapter.v1.Hello
+	(*Item)(nil),                  // 3: policyreportpublisher.adapter.v1.Item
+	(*Result)(nil),                // 4: policyreportpublisher.adapter.v1.Result
+	(*PublisherMessage)(nil),      // 5: policyreportpublisher.adapter.v1.PublisherMessage
+	(*Config)(nil),                // 6: policyreportpublisher.adapter.v1.Config
+	(*Health)(nil),                // 7: policyreportpublisher.adapter.v1.Health
+	nil,                           // 8: policyreportpublisher.adapter.v1.Result.PropertiesEntry
+	nil,                           // 9: policyreportpublisher.adapter.v1.Config.SettingsEntry
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_api_adapter_v1_adapter_proto_depIdxs = []int32{
+	2,  // 0: policyreportpublisher.adapter.v1.AdapterMessage.hello:type_name -> policyreportpublisher.adapter.v1.Hello
+	3,  // 1: policyreportpublisher.adapter.v1.AdapterMessage.item:type_name -> policyreportpublisher.adapter.v1.Item
+	4,  // 2: policyreportpublisher.adapter.v1.Item.result:type_name -> policyreportpublisher.adapter.v1.Result
+	10, // 3: policyreportpublisher.adapter.v1.Result.timestamp:type_name -> google.protobuf.Timestamp
+	8,  // 4: policyreportpublisher.adapter.v1.Result.properties:type_name -> policyreportpublisher.adapter.v1.Result.PropertiesEntry
+	6,  // 5: policyreportpublisher.adapter.v1.PublisherMessage.config:type_name -> policyreportpublisher.adapter.v1.Config
+	7,  // 6: policyreportpublisher.adapter.v1.PublisherMessage.health:type_name -> policyreportpublisher.adapter.v1.Health
+	9,  // 7: policyreportpublisher.adapter.v1.Config.settings:type_name -> policyreportpublisher.adapter.v1.Config.SettingsEntry
+	0,  // 8: policyreportpublisher.adapter.v1.Health.status:type_name -> policyreportpublisher.adapter.v1.Health.Status
+	1,  // 9: policyreportpublisher.adapter.v1.AdapterService.Connect:input_type -> policyreportpublisher.adapter.v1.AdapterMessage
+	5,  // 10: policyreportpublisher.adapter.v1.AdapterService.Connect:output_type -> policyreportpublisher.adapter.v1.PublisherMessage
+	10, // [10:11] is the sub-list for method output_type
+	9,  // [9:10] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_api_adapter_v1_adapter_proto_init() }
+func file_api_adapter_v1_adapter_proto_init() {
+	if File_api_adapter_v1_adapter_proto != nil {
+		return
+	}
+	file_api_adapter_v1_adapter_proto_msgTypes[0].OneofWrappers = []any{
+		(*AdapterMessage_Hello)(nil),
+		(*AdapterMessage_Item)(nil),
+	}
+	file_api_adapter_v1_adapter_proto_msgTypes[3].OneofWrappers = []any{}
+	file_api_adapter_v1_adapter_proto_msgTypes[4].OneofWrappers = []any{
+		(*PublisherMessage_Config)(nil),
+		(*PublisherMessage_Health)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_adapter_v1_adapter_proto_rawDesc), len(file_api_adapter_v1_adapter_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_adapter_v1_adapter_proto_goTypes,
+		DependencyIndexes: file_api_adapter_v1_adapter_proto_depIdxs,
+		EnumInfos:         file_api_adapter_v1_adapter_proto_enumTypes,
+		MessageInfos:      file_api_adapter_v1_adapter_proto_msgTypes,
+	}.Build()
+	File_api_adapter_v1_adapter_proto = out.File
+	file_api_adapter_v1_adapter_proto_goTypes = nil
+	file_api_adapter_v1_adapter_proto_depIdxs = nil
+}
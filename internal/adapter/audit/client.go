@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/webhook"
+)
+
+// Run receives the audit events of the kube-apiserver audit webhook backend
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	address, ok := os.LookupEnv(env.AuditWebhookAddress)
+	if !ok {
+		return fmt.Errorf("audit webhook address variable must %q be set", env.AuditWebhookAddress)
+	}
+
+	t := webhook.TLS{
+		CertFile:     os.Getenv(env.AuditWebhookTLSCert),
+		KeyFile:      os.Getenv(env.AuditWebhookTLSKey),
+		ClientCAFile: os.Getenv(env.AuditWebhookTLSClientCA),
+	}
+	token := os.Getenv(env.AuditWebhookToken)
+	if token == "" && t.ClientCAFile == "" {
+		slog.WarnContext(ctx, "audit webhook accepts unauthenticated requests",
+			"token", env.AuditWebhookToken, "client-ca", env.AuditWebhookTLSClientCA)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		list := &EventList{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize)).Decode(list); err != nil {
+			http.Error(w, fmt.Sprintf("error unmarshalling event list: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		for _, e := range list.Items {
+			if item := e.toItem(); item != nil {
+				select {
+				case reportChan <- item:
+				case <-ctx.Done():
+					http.Error(w, "shutting down", http.StatusServiceUnavailable)
+					return
+				}
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return webhook.ServeTLS(ctx, "Audit", address, t, webhook.Authorize(token, mux))
+}
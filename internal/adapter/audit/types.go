@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reportSource = "Kubernetes Audit"
+
+	stageResponseComplete = "ResponseComplete"
+	serviceAccountPrefix  = "system:serviceaccount:"
+
+	extraPodName = "authentication.kubernetes.io/pod-name"
+
+	annotationAuthorizationDecision = "authorization.k8s.io/decision"
+	annotationAuthorizationReason   = "authorization.k8s.io/reason"
+	annotationPodSecurityEnforce    = "pod-security.kubernetes.io/enforce-policy"
+	annotationValidationFailure     = "validation.policy.admission.k8s.io/validation_failure"
+
+	categoryRBAC        = "RBAC"
+	categoryPodSecurity = "PodSecurity"
+	categoryVAP         = "ValidatingAdmissionPolicy"
+)
+
+// EventList is the audit.k8s.io/v1 EventList sent by the audit webhook backend
+type EventList struct {
+	Items []Event `json:"items"`
+}
+
+// Event is the subset of the audit.k8s.io/v1 Event needed to report denied requests
+type Event struct {
+	AuditID    string `json:"auditID"`
+	Stage      string `json:"stage"`
+	RequestURI string `json:"requestURI"`
+	Verb       string `json:"verb"`
+	User       struct {
+		Username string              `json:"username"`
+		Groups   []string            `json:"groups"`
+		Extra    map[string][]string `json:"extra"`
+	} `json:"user"`
+	SourceIPs []string `json:"sourceIPs"`
+	UserAgent string   `json:"userAgent"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		APIGroup    string `json:"apiGroup"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *metav1.Status    `json:"responseStatus"`
+	StageTimestamp metav1.MicroTime  `json:"stageTimestamp"`
+	Annotations    map[string]string `json:"annotations"`
+}
+
+// validationFailure is an entry of the validation_failure annotation of a ValidatingAdmissionPolicy
+type validationFailure struct {
+	Message           string   `json:"message"`
+	Policy            string   `json:"policy"`
+	Binding           string   `json:"binding"`
+	ValidationActions []string `json:"validationActions"`
+}
+
+func (e Event) toItem() *report.Item {
+	if e.Stage != stageResponseComplete || e.ResponseStatus == nil {
+		return nil
+	}
+
+	category, policy := e.denial()
+	if category == "" {
+		return nil
+	}
+
+	pr := prv1alpha2.PolicyReportResult{
+		Category: category,
+		Message:  e.ResponseStatus.Message,
+
+		Severity: "medium",
+		Policy:   policy,
+		Rule:     fmt.Sprintf("%s %s", e.Verb, e.resource()),
+		Result:   "fail",
+		Scored:   report.Scored("fail"),
+		Source:   reportSource,
+		Timestamp: metav1.Timestamp{
+			Seconds: e.StageTimestamp.Unix(),
+			Nanos:   int32(e.StageTimestamp.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: map[string]string{
+			report.PropertyCreated: e.UpdatedTimeRFC3339(),
+			report.PropertyUpdated: e.UpdatedTimeRFC3339(),
+			"user":                 e.User.Username,
+			"user-agent":           e.UserAgent,
+			"request-uri":          e.RequestURI,
+			"code":                 fmt.Sprint(e.ResponseStatus.Code),
+			"audit-id":             e.AuditID,
+		},
+	}
+	if reason := e.Annotations[annotationAuthorizationReason]; reason != "" {
+		pr.Properties["reason"] = reason
+	}
+	if e.ObjectRef != nil && e.ObjectRef.Name != "" {
+		pr.Properties["object"] = e.ObjectRef.Name
+	}
+
+	// report on the pod of the requesting service account if known, otherwise on the namespace
+	namespace, sa := e.serviceAccount()
+	if pods := e.User.Extra[extraPodName]; namespace != "" && len(pods) == 1 {
+		pr.Properties["service-account"] = sa
+		return report.ItemFor("audit", namespace, pods[0], pr, &e)
+	}
+	if e.ObjectRef != nil && e.ObjectRef.Namespace != "" {
+		namespace = e.ObjectRef.Namespace
+	}
+	if namespace == "" {
+		// cluster scoped request of a user
+		return nil
+	}
+	return report.NamespaceItemFor("audit", namespace, pr, &e)
+}
+
+// denial returns the category and policy of a denied request, or an empty category if the request was not denied
+func (e Event) denial() (string, string) {
+	if failures := e.Annotations[annotationValidationFailure]; failures != "" && e.ResponseStatus.Code >= http.StatusBadRequest {
+		var vf []validationFailure
+		if err := json.Unmarshal([]byte(failures), &vf); err == nil && len(vf) > 0 {
+			return categoryVAP, vf[0].Policy
+		}
+		return categoryVAP, categoryVAP
+	}
+
+	if e.ResponseStatus.Code != http.StatusForbidden {
+		return "", ""
+	}
+	if e.Annotations[annotationAuthorizationDecision] == "forbid" {
+		return categoryRBAC, categoryRBAC
+	}
+	if policy := e.Annotations[annotationPodSecurityEnforce]; policy != "" &&
+		strings.Contains(e.ResponseStatus.Message, "violates PodSecurity") {
+		return categoryPodSecurity, categoryPodSecurity + " " + policy
+	}
+	return "", ""
+}
+
+func (e Event) resource() string {
+	if e.ObjectRef == nil {
+		return e.RequestURI
+	}
+	r := e.ObjectRef.Resource
+	if e.ObjectRef.APIGroup != "" {
+		r = e.ObjectRef.APIGroup + "/" + r
+	}
+	if e.ObjectRef.Subresource != "" {
+		r += "/" + e.ObjectRef.Subresource
+	}
+	return r
+}
+
+// serviceAccount returns the namespace and name of the requesting service account
+func (e Event) serviceAccount() (string, string) {
+	if !strings.HasPrefix(e.User.Username, serviceAccountPrefix) {
+		return "", ""
+	}
+	ns, sa, ok := strings.Cut(strings.TrimPrefix(e.User.Username, serviceAccountPrefix), ":")
+	if !ok {
+		return "", ""
+	}
+	return ns, sa
+}
+
+func (e Event) UpdatedTimeRFC3339() string {
+	return e.StageTimestamp.Format(time.RFC3339)
+}
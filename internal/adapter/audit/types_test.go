@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"net/http"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventDenial(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         int32
+		message      string
+		annotations  map[string]string
+		wantCategory string
+		wantPolicy   string
+	}{
+		{
+			name:         "forbidden by rbac",
+			code:         http.StatusForbidden,
+			annotations:  map[string]string{annotationAuthorizationDecision: "forbid"},
+			wantCategory: categoryRBAC,
+			wantPolicy:   categoryRBAC,
+		},
+		{
+			name:        "allowed by rbac",
+			code:        http.StatusOK,
+			annotations: map[string]string{annotationAuthorizationDecision: "allow"},
+		},
+		{
+			name:         "rejected by pod security",
+			code:         http.StatusForbidden,
+			message:      `pods "nginx" is forbidden: violates PodSecurity "restricted:latest": allowPrivilegeEscalation != false`,
+			annotations:  map[string]string{annotationPodSecurityEnforce: "restricted:latest"},
+			wantCategory: categoryPodSecurity,
+			wantPolicy:   "PodSecurity restricted:latest",
+		},
+		{
+			name:        "pod security enforce policy without violation",
+			code:        http.StatusForbidden,
+			message:     `pods "nginx" is forbidden: exceeded quota`,
+			annotations: map[string]string{annotationPodSecurityEnforce: "restricted:latest"},
+		},
+		{
+			name:    "forbidden without annotation",
+			code:    http.StatusForbidden,
+			message: "forbidden",
+		},
+		{
+			name: "rejected by a validating admission policy",
+			code: http.StatusUnprocessableEntity,
+			annotations: map[string]string{
+				annotationValidationFailure: `[{"message":"replicas must be below 5","policy":"max-replicas","binding":"max-replicas-binding","validationActions":["Deny"]}]`,
+			},
+			wantCategory: categoryVAP,
+			wantPolicy:   "max-replicas",
+		},
+		{
+			name:         "invalid validation failure annotation",
+			code:         http.StatusForbidden,
+			annotations:  map[string]string{annotationValidationFailure: "invalid"},
+			wantCategory: categoryVAP,
+			wantPolicy:   categoryVAP,
+		},
+		{
+			name: "audited by a validating admission policy",
+			code: http.StatusCreated,
+			annotations: map[string]string{
+				annotationValidationFailure: `[{"message":"replicas must be below 5","policy":"max-replicas","validationActions":["Audit"]}]`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Event{
+				ResponseStatus: &metav1.Status{Code: tt.code, Message: tt.message},
+				Annotations:    tt.annotations,
+			}
+			category, policy := e.denial()
+			if category != tt.wantCategory || policy != tt.wantPolicy {
+				t.Errorf("denial() = (%q, %q), want (%q, %q)", category, policy, tt.wantCategory, tt.wantPolicy)
+			}
+		})
+	}
+}
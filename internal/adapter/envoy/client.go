@@ -0,0 +1,172 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/grpcserver"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v3"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	podCacheTTL  = time.Minute
+	podCacheSize = 1024
+)
+
+// Run implements the Envoy gRPC Access Log Service and reports the requests denied by RBAC or ext_authz
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	address, ok := os.LookupEnv(env.EnvoyALSAddress)
+	if !ok {
+		return fmt.Errorf("envoy access log service address variable must %q be set", env.EnvoyALSAddress)
+	}
+
+	config, err := report.RestConfig("")
+	if err != nil {
+		return err
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	s := &server{
+		ctx:        ctx,
+		reportChan: reportChan,
+		pods:       &podResolver{pods: cs.CoreV1().Pods(metav1.NamespaceAll), cache: map[string]cachedPod{}},
+	}
+	return grpcserver.Serve(ctx, "Envoy", address, func(gs *grpc.Server) {
+		accesslogv3.RegisterAccessLogServiceServer(gs, s)
+	})
+}
+
+type server struct {
+	accesslogv3.UnimplementedAccessLogServiceServer
+	ctx        context.Context //nolint:containedctx // the adapter context stops the streams
+	reportChan chan *report.Item
+	pods       *podResolver
+}
+
+func (s *server) StreamAccessLogs(stream accesslogv3.AccessLogService_StreamAccessLogsServer) error {
+	msgs := make(chan *accesslogv3.StreamAccessLogsMessage)
+	errChan := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				errChan <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	// the identifier is only sent with the first message of a stream
+	var node string
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case err := <-errChan:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case msg := <-msgs:
+			if id := msg.GetIdentifier(); id != nil {
+				node = id.GetNode().GetId()
+			}
+			for _, e := range msg.GetHttpLogs().GetLogEntry() {
+				if d := httpDenial(node, e); d != nil {
+					s.report(d, e)
+				}
+			}
+			for _, e := range msg.GetTcpLogs().GetLogEntry() {
+				if d := tcpDenial(node, e); d != nil {
+					s.report(d, e)
+				}
+			}
+		}
+	}
+}
+
+func (s *server) report(d *denial, entry any) {
+	var source *types.NamespacedName
+	if d.sourceIP != "" {
+		source = s.pods.resolve(s.ctx, d.sourceIP)
+	}
+	item := d.toItem(source, entry)
+	if item == nil {
+		return
+	}
+	select {
+	case s.reportChan <- item:
+	case <-s.ctx.Done():
+	}
+}
+
+// podResolver finds the pod of a downstream address, the results are cached to protect the API server
+type podResolver struct {
+	pods  corev1client.PodInterface
+	mux   sync.Mutex
+	cache map[string]cachedPod
+}
+
+type cachedPod struct {
+	pod     *types.NamespacedName
+	expires time.Time
+}
+
+func (r *podResolver) resolve(ctx context.Context, ip string) *types.NamespacedName {
+	now := time.Now()
+	r.mux.Lock()
+	c, ok := r.cache[ip]
+	r.mux.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.pod
+	}
+
+	// the lock is not held during the lookup, the streams of other proxies must not wait for the API server
+	list, err := r.pods.List(ctx, metav1.ListOptions{FieldSelector: "status.podIP=" + ip})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find the pod of the source address", "ip", ip, "error", err)
+		return nil
+	}
+	var pod *types.NamespacedName
+	for _, p := range list.Items {
+		// host network pods share the address of the node
+		if !p.Spec.HostNetwork && p.Status.Phase == corev1.PodRunning {
+			pod = &types.NamespacedName{Namespace: p.Namespace, Name: p.Name}
+			break
+		}
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(r.cache) >= podCacheSize {
+		for k, c := range r.cache {
+			if now.After(c.expires) {
+				delete(r.cache, k)
+			}
+		}
+	}
+	r.cache[ip] = cachedPod{pod: pod, expires: now.Add(podCacheTTL)}
+	return pod
+}
@@ -0,0 +1,191 @@
+package envoy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	accesslogdatav3 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	reportSource = "Envoy"
+
+	categoryRBAC      = "RBAC"
+	categoryExtAuthz  = "ExtAuthz"
+	rbacDeniedPrefix  = "rbac_access_denied_matched_policy["
+	policyNoneMatched = "none"
+	// policyNoAllowMatched is reported if no ALLOW policy matched the request
+	policyNoAllowMatched = "no-allow-policy-matched"
+
+	spiffePrefix = "spiffe://"
+)
+
+// istioPolicy matches the Istio AuthorizationPolicy in the RBAC details: ns[<namespace>]-policy[<name>]-rule[<index>]
+var istioPolicy = regexp.MustCompile(`^ns\[([^\]]+)\]-policy\[([^\]]+)\]`)
+
+// denial is an access log entry of a request denied by an authorization filter
+type denial struct {
+	category    string
+	policy      string
+	rule        string
+	message     string
+	sourceIP    string
+	principal   string
+	destination string
+	timestamp   time.Time
+	properties  map[string]string
+}
+
+// httpDenial returns the denial of an HTTP entry or nil if the request was not denied
+func httpDenial(node string, e *accesslogdatav3.HTTPAccessLogEntry) *denial {
+	cp := e.GetCommonProperties()
+	details := e.GetResponse().GetResponseCodeDetails()
+
+	d := newDenial(node, cp)
+	switch {
+	case strings.HasPrefix(details, rbacDeniedPrefix):
+		d.category = categoryRBAC
+		d.policy = rbacPolicy(details)
+	case cp.GetResponseFlags().GetUnauthorizedDetails() != nil:
+		d.category = categoryExtAuthz
+		d.policy = categoryExtAuthz
+	default:
+		return nil
+	}
+
+	req := e.GetRequest()
+	path, _, _ := strings.Cut(req.GetPath(), "?")
+	// the ids in the path are replaced, so the requests to the same resource type share a result
+	d.rule = fmt.Sprintf("%s %s%s", req.GetRequestMethod(), req.GetAuthority(), report.PathTemplate(path))
+	d.message = fmt.Sprintf("request %s %s%s was denied with %d: %s",
+		req.GetRequestMethod(), req.GetAuthority(), path, e.GetResponse().GetResponseCode().GetValue(), details)
+	d.properties["response-code"] = fmt.Sprint(e.GetResponse().GetResponseCode().GetValue())
+	d.properties["response-code-details"] = details
+	if id := req.GetRequestId(); id != "" {
+		d.properties["request-id"] = id
+	}
+	if route := cp.GetRouteName(); route != "" {
+		d.properties["route"] = route
+	}
+	return d
+}
+
+// tcpDenial returns the denial of a TCP entry or nil if the connection was not denied
+func tcpDenial(node string, e *accesslogdatav3.TCPAccessLogEntry) *denial {
+	cp := e.GetCommonProperties()
+	details := cp.GetConnectionTerminationDetails()
+	if !strings.HasPrefix(details, rbacDeniedPrefix) {
+		return nil
+	}
+
+	d := newDenial(node, cp)
+	d.category = categoryRBAC
+	d.policy = rbacPolicy(details)
+	d.rule = "TCP " + tcpDestination(cp)
+	d.message = fmt.Sprintf("connection %s was denied: %s", d.rule, details)
+	d.properties["connection-termination-details"] = details
+	return d
+}
+
+func newDenial(node string, cp *accesslogdatav3.AccessLogCommon) *denial {
+	d := &denial{
+		sourceIP:    cp.GetDownstreamRemoteAddress().GetSocketAddress().GetAddress(),
+		destination: nodeWorkload(node),
+		timestamp:   cp.GetStartTime().AsTime(),
+		properties:  map[string]string{},
+	}
+	for _, san := range cp.GetTlsProperties().GetPeerCertificateProperties().GetSubjectAltName() {
+		if uri := san.GetUri(); strings.HasPrefix(uri, spiffePrefix) {
+			d.principal = uri
+			d.properties["source-principal"] = uri
+		}
+	}
+	if d.sourceIP != "" {
+		d.properties["source-ip"] = d.sourceIP
+	}
+	if d.destination != "" {
+		d.properties["destination"] = d.destination
+	}
+	if c := cp.GetUpstreamCluster(); c != "" {
+		d.properties["upstream-cluster"] = c
+	}
+	return d
+}
+
+// rbacPolicy returns the policy of the RBAC details rbac_access_denied_matched_policy[<policy>]
+func rbacPolicy(details string) string {
+	p := strings.TrimSuffix(strings.TrimPrefix(details, rbacDeniedPrefix), "]")
+	if p == policyNoneMatched {
+		return policyNoAllowMatched
+	}
+	if m := istioPolicy.FindStringSubmatch(p); m != nil {
+		return m[1] + "/" + m[2]
+	}
+	return p
+}
+
+// tcpDestination returns the service of an outbound cluster (outbound|<port>||<host>) or the local port
+func tcpDestination(cp *accesslogdatav3.AccessLogCommon) string {
+	if parts := strings.Split(cp.GetUpstreamCluster(), "|"); len(parts) == 4 && parts[3] != "" {
+		return fmt.Sprintf("%s:%s", parts[3], parts[1])
+	}
+	return fmt.Sprintf(":%d", cp.GetDownstreamLocalAddress().GetSocketAddress().GetPortValue())
+}
+
+// nodeWorkload returns <namespace>/<pod> of an Istio proxy node id: <type>~<ip>~<pod>.<namespace>~<domain>
+func nodeWorkload(node string) string {
+	parts := strings.Split(node, "~")
+	if len(parts) != 4 {
+		return node
+	}
+	pod, ns, ok := strings.Cut(parts[2], ".")
+	if !ok {
+		return parts[2]
+	}
+	return ns + "/" + pod
+}
+
+// principalNamespace returns the namespace of a SPIFFE id: spiffe://<trust-domain>/ns/<namespace>/sa/<service-account>
+func principalNamespace(principal string) string {
+	parts := strings.Split(strings.TrimPrefix(principal, spiffePrefix), "/")
+	if len(parts) == 5 && parts[1] == "ns" {
+		return parts[2]
+	}
+	return ""
+}
+
+// toItem returns the item on the source pod, or on the namespace of the source principal if the pod is unknown
+func (d *denial) toItem(source *types.NamespacedName, entry any) *report.Item {
+	pr := prv1alpha2.PolicyReportResult{
+		Category: d.category,
+		Message:  d.message,
+
+		Severity: "medium",
+		Policy:   d.policy,
+		Rule:     d.rule,
+		Result:   "fail",
+		Scored:   report.Scored("fail"),
+		Source:   reportSource,
+		Timestamp: metav1.Timestamp{
+			Seconds: d.timestamp.Unix(),
+			Nanos:   int32(d.timestamp.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: d.properties,
+	}
+	pr.Properties[report.PropertyCreated] = d.timestamp.Format(time.RFC3339)
+	pr.Properties[report.PropertyUpdated] = d.timestamp.Format(time.RFC3339)
+
+	if source != nil {
+		return report.ItemFor("envoy", source.Namespace, source.Name, pr, entry)
+	}
+	if ns := principalNamespace(d.principal); ns != "" {
+		return report.NamespaceItemFor("envoy", ns, pr, entry)
+	}
+	return nil
+}
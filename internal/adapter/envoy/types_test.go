@@ -0,0 +1,83 @@
+package envoy
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	accesslogdatav3 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRBACPolicy(t *testing.T) {
+	tests := []struct {
+		details string
+		want    string
+	}{
+		{details: "rbac_access_denied_matched_policy[none]", want: policyNoAllowMatched},
+		{details: "rbac_access_denied_matched_policy[ns[shop]-policy[deny-orders]-rule[0]]", want: "shop/deny-orders"},
+		{details: "rbac_access_denied_matched_policy[custom-policy]", want: "custom-policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.details, func(t *testing.T) {
+			if got := rbacPolicy(tt.details); got != tt.want {
+				t.Errorf("rbacPolicy(%q) = %q, want %q", tt.details, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPDenialRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		details  string
+		wantRule string
+	}{
+		{
+			name:     "path with id",
+			path:     "/orders/42?expand=items",
+			details:  "rbac_access_denied_matched_policy[none]",
+			wantRule: "GET shop:8080/orders/{id}",
+		},
+		{
+			name:     "path without id",
+			path:     "/orders",
+			details:  "rbac_access_denied_matched_policy[none]",
+			wantRule: "GET shop:8080/orders",
+		},
+		{
+			name:    "not denied",
+			path:    "/orders/42",
+			details: "via_upstream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &accesslogdatav3.HTTPAccessLogEntry{
+				CommonProperties: &accesslogdatav3.AccessLogCommon{},
+				Request: &accesslogdatav3.HTTPRequestProperties{
+					RequestMethod: corev3.RequestMethod_GET,
+					Authority:     "shop:8080",
+					Path:          tt.path,
+				},
+				Response: &accesslogdatav3.HTTPResponseProperties{
+					ResponseCode:        wrapperspb.UInt32(403),
+					ResponseCodeDetails: tt.details,
+				},
+			}
+			d := httpDenial("", e)
+			if tt.wantRule == "" {
+				if d != nil {
+					t.Errorf("httpDenial() = %+v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("httpDenial() = nil")
+			}
+			if d.rule != tt.wantRule {
+				t.Errorf("rule = %q, want %q", d.rule, tt.wantRule)
+			}
+		})
+	}
+}
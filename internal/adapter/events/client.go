@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+const allReasons = "*"
+
+// +kubebuilder:rbac:groups=,resources=events,verbs=get;list;watch
+
+// Run watches the core Events of all namespaces and reports the ones matching the configured filters
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	f, err := filterFromEnv()
+	if err != nil {
+		return err
+	}
+
+	config, err := report.RestConfig("")
+	if err != nil {
+		return err
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return watchEvents(ctx, cs.CoreV1().Events(metav1.NamespaceAll), f, reportChan)
+}
+
+// watchEvents reports the events created or occurring again after the start
+func watchEvents(ctx context.Context, events typedcorev1.EventInterface, f *filter, reportChan chan *report.Item) error {
+	// the existing events were already reported by the previous run, only new ones are watched
+	list, err := events.List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return err
+	}
+
+	w, err := watchtools.NewRetryWatcherWithContext(ctx, list.ResourceVersion, &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return events.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	// the last reported count by event uid, an event is modified when it occurs again but also on other updates
+	counts := map[types.UID]int32{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.Done():
+			// the retry watcher is also stopped by the cancelled ctx
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("event watch stopped")
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("event watch closed")
+			}
+			if e.Type == watch.Error {
+				slog.ErrorContext(ctx, "error watching events", "error", e.Object)
+				continue
+			}
+			event, ok := e.Object.(*corev1.Event)
+			if !ok {
+				continue
+			}
+			if e.Type == watch.Deleted {
+				delete(counts, event.UID)
+				continue
+			}
+			if (e.Type != watch.Added && e.Type != watch.Modified) || !f.matches(event) {
+				continue
+			}
+			if c, ok := counts[event.UID]; ok && c == count(event) {
+				continue
+			}
+			counts[event.UID] = count(event)
+
+			select {
+			case reportChan <- toItem(event):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+type filter struct {
+	reasons []string
+	kinds   []string
+	types   []string
+}
+
+func filterFromEnv() (*filter, error) {
+	reasons := env.List(env.KubeEventsReasons, nil)
+	if len(reasons) == 0 {
+		return nil, fmt.Errorf("kubernetes events reasons variable must %q be set", env.KubeEventsReasons)
+	}
+	if slices.Contains(reasons, allReasons) {
+		reasons = nil
+	}
+	f := &filter{
+		reasons: reasons,
+		kinds:   env.List(env.KubeEventsInvolvedKinds, nil),
+		types:   env.List(env.KubeEventsTypes, []string{corev1.EventTypeWarning}),
+	}
+	slog.Info("kubernetes events filter", "reasons", os.Getenv(env.KubeEventsReasons),
+		"involved-kinds", f.kinds, "types", f.types)
+	return f, nil
+}
+
+// matches returns true if the event passes all filters, an empty filter matches all events
+func (f *filter) matches(e *corev1.Event) bool {
+	if e.InvolvedObject.Namespace == "" {
+		// cluster scoped objects have no namespace to report on
+		return false
+	}
+	return (len(f.reasons) == 0 || slices.Contains(f.reasons, e.Reason)) &&
+		(len(f.kinds) == 0 || slices.Contains(f.kinds, e.InvolvedObject.Kind)) &&
+		(len(f.types) == 0 || slices.Contains(f.types, e.Type))
+}
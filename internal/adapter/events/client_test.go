@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatchEvents(t *testing.T) {
+	cs := fake.NewClientset()
+	cs.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.EventList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}}, nil
+	})
+	fw := watch.NewFakeWithChanSize(10, false)
+	watchedFrom := make(chan string, 1)
+	cs.PrependWatchReactor("events", func(a k8stesting.Action) (bool, watch.Interface, error) {
+		watchedFrom <- a.(k8stesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion
+		return true, fw, nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	reportChan := make(chan *report.Item)
+	done := make(chan error)
+	go func() {
+		done <- watchEvents(ctx, cs.CoreV1().Events(metav1.NamespaceAll), &filter{}, reportChan)
+	}()
+
+	// the existing events are not reported, only the ones after the listed resource version
+	if rv := <-watchedFrom; rv != "42" {
+		t.Errorf("watched from resource version %q, want 42", rv)
+	}
+
+	fw.Add(testEvent("a", 1, "43"))
+	// an update without a new occurrence
+	fw.Modify(testEvent("a", 1, "44"))
+	fw.Add(testEvent("b", 1, "45"))
+	fw.Modify(testEvent("a", 2, "46"))
+	fw.Delete(testEvent("a", 2, "47"))
+	// a recreated event is reported again
+	fw.Add(testEvent("a", 2, "48"))
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case item := <-reportChan:
+			got = append(got, item.Result().Message)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("reported events = %v, want 4", got)
+		}
+	}
+	want := []string{"a occurred 1 times", "b occurred 1 times", "a occurred 2 times", "a occurred 2 times"}
+	if !slices.Equal(got, want) {
+		t.Errorf("reported events = %v, want %v", got, want)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watchEvents() error = %v", err)
+	}
+}
+
+func testEvent(name string, count int32, resourceVersion string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			UID:             types.UID(name),
+			ResourceVersion: resourceVersion,
+		},
+		InvolvedObject: corev1.ObjectReference{Kind: kindPod, Namespace: "default", Name: "nginx"},
+		Reason:         "BackOff",
+		Message:        fmt.Sprintf("%s occurred %d times", name, count),
+		Type:           corev1.EventTypeWarning,
+		Count:          count,
+	}
+}
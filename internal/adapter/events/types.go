@@ -0,0 +1,92 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reportSource = "Kubernetes Events"
+	kindPod      = "Pod"
+)
+
+func toItem(e *corev1.Event) *report.Item {
+	var result prv1alpha2.PolicyResult = "warn"
+	var severity prv1alpha2.PolicySeverity = "info"
+	if e.Type == corev1.EventTypeWarning {
+		result = "fail"
+		severity = "medium"
+	}
+
+	created := firstSeen(e)
+	updated := lastSeen(e)
+	pr := prv1alpha2.PolicyReportResult{
+		Category: reportingController(e),
+		Message:  e.Message,
+
+		Severity: severity,
+		Policy:   e.Reason,
+		Rule:     fmt.Sprintf("%s/%s", e.InvolvedObject.Kind, e.InvolvedObject.Name),
+		Result:   result,
+		Scored:   report.Scored(result),
+		Source:   reportSource,
+		Timestamp: metav1.Timestamp{
+			Seconds: updated.Unix(),
+			Nanos:   int32(updated.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: map[string]string{
+			report.PropertyCreated: created.Format(time.RFC3339),
+			report.PropertyUpdated: updated.Format(time.RFC3339),
+			"type":                 e.Type,
+			"kind":                 e.InvolvedObject.Kind,
+			"object":               e.InvolvedObject.Name,
+			"event":                e.Name,
+		},
+	}
+	if e.InvolvedObject.FieldPath != "" {
+		pr.Properties["field-path"] = e.InvolvedObject.FieldPath
+	}
+
+	if e.InvolvedObject.Kind == kindPod {
+		return report.ItemFor("events", e.InvolvedObject.Namespace, e.InvolvedObject.Name, pr, e)
+	}
+	return report.NamespaceItemFor("events", e.InvolvedObject.Namespace, pr, e)
+}
+
+func reportingController(e *corev1.Event) string {
+	if e.ReportingController != "" {
+		return e.ReportingController
+	}
+	return e.Source.Component
+}
+
+func firstSeen(e *corev1.Event) time.Time {
+	if !e.FirstTimestamp.IsZero() {
+		return e.FirstTimestamp.Time
+	}
+	return e.EventTime.Time
+}
+
+// count returns the number of occurrences, events of the events.k8s.io API track it in the series
+func count(e *corev1.Event) int32 {
+	if e.Series != nil {
+		return e.Series.Count
+	}
+	return e.Count
+}
+
+// lastSeen returns the time of the last occurrence, events of the events.k8s.io API track it in the series
+func lastSeen(e *corev1.Event) time.Time {
+	if e.Series != nil && !e.Series.LastObservedTime.IsZero() {
+		return e.Series.LastObservedTime.Time
+	}
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	return firstSeen(e)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestToItem(t *testing.T) {
+	tests := []struct {
+		name          string
+		eventType     string
+		kind          string
+		wantResult    prv1alpha2.PolicyResult
+		wantSeverity  prv1alpha2.PolicySeverity
+		wantName      string
+		wantNamespace bool
+	}{
+		{
+			name:         "warning of a pod",
+			eventType:    corev1.EventTypeWarning,
+			kind:         kindPod,
+			wantResult:   "fail",
+			wantSeverity: "medium",
+			wantName:     "nginx",
+		},
+		{
+			name:         "normal event of a pod",
+			eventType:    corev1.EventTypeNormal,
+			kind:         kindPod,
+			wantResult:   "warn",
+			wantSeverity: "info",
+			wantName:     "nginx",
+		},
+		{
+			name:          "warning of a deployment",
+			eventType:     corev1.EventTypeWarning,
+			kind:          "Deployment",
+			wantResult:    "fail",
+			wantSeverity:  "medium",
+			wantNamespace: true,
+		},
+		{
+			name:          "normal event of a deployment",
+			eventType:     corev1.EventTypeNormal,
+			kind:          "Deployment",
+			wantResult:    "warn",
+			wantSeverity:  "info",
+			wantNamespace: true,
+		},
+	}
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &corev1.Event{
+				ObjectMeta:          metav1.ObjectMeta{Namespace: "shop", Name: "nginx.17c"},
+				InvolvedObject:      corev1.ObjectReference{Kind: tt.kind, Namespace: "shop", Name: "nginx"},
+				Reason:              "BackOff",
+				Message:             "Back-off restarting failed container",
+				Type:                tt.eventType,
+				ReportingController: "kubelet",
+				FirstTimestamp:      metav1.NewTime(first),
+				LastTimestamp:       metav1.NewTime(first.Add(time.Minute)),
+			}
+			item := toItem(e)
+			if item.Namespace != "shop" || item.Name != tt.wantName || item.NamespaceScoped() != tt.wantNamespace {
+				t.Errorf("item = %s/%s (namespace scoped %v), want shop/%s (namespace scoped %v)",
+					item.Namespace, item.Name, item.NamespaceScoped(), tt.wantName, tt.wantNamespace)
+			}
+			r := item.Result()
+			if r.Result != tt.wantResult || r.Severity != tt.wantSeverity {
+				t.Errorf("result, severity = %q, %q, want %q, %q", r.Result, r.Severity, tt.wantResult, tt.wantSeverity)
+			}
+			if r.Policy != "BackOff" || r.Rule != tt.kind+"/nginx" || r.Category != "kubelet" {
+				t.Errorf("policy, rule, category = %q, %q, %q", r.Policy, r.Rule, r.Category)
+			}
+			if r.Properties["created"] != "2024-05-01T10:00:00Z" || r.Properties["updated"] != "2024-05-01T10:01:00Z" {
+				t.Errorf("created, updated = %q, %q", r.Properties["created"], r.Properties["updated"])
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	if got := count(&corev1.Event{Count: 3}); got != 3 {
+		t.Errorf("count() = %d, want 3", got)
+	}
+	if got := count(&corev1.Event{Count: 1, Series: &corev1.EventSeries{Count: 5}}); got != 5 {
+		t.Errorf("count() of a series = %d, want 5", got)
+	}
+}
@@ -0,0 +1,149 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	adapterv1 "github.com/bakito/policy-report-publisher/api/adapter/v1"
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/grpcserver"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Run serves the AdapterService for adapters running in separate processes, e.g. sidecars
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	address, ok := os.LookupEnv(env.ExternalAdapterAddress)
+	if !ok {
+		return fmt.Errorf("external adapter address variable must %q be set", env.ExternalAdapterAddress)
+	}
+
+	// the names are the handler ids of the items and therefore the label values of the metrics
+	names := map[string]bool{}
+	for _, name := range env.List(env.ExternalAdapterNames, nil) {
+		if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+			return fmt.Errorf("invalid adapter name %q in %q: %s", name, env.ExternalAdapterNames, strings.Join(errs, ", "))
+		}
+		names[name] = true
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("external adapter names variable must %q be set", env.ExternalAdapterNames)
+	}
+	token := os.Getenv(env.ExternalAdapterToken)
+	if token == "" {
+		slog.WarnContext(ctx, "external adapter service accepts unauthenticated calls", "variable", env.ExternalAdapterToken)
+	}
+
+	return grpcserver.Serve(ctx, "External", address, func(s *grpc.Server) {
+		adapterv1.RegisterAdapterServiceServer(s, &server{ctx: ctx, reportChan: reportChan, names: names})
+	}, grpcserver.Authorize(token)...)
+}
+
+type server struct {
+	adapterv1.UnimplementedAdapterServiceServer
+	ctx        context.Context //nolint:containedctx // the adapter context stops the streams
+	reportChan chan *report.Item
+	// names are the names of the adapters allowed to connect
+	names map[string]bool
+}
+
+func (s *server) Connect(stream grpc.BidiStreamingServer[adapterv1.AdapterMessage, adapterv1.PublisherMessage]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	hello := first.GetHello()
+	if hello == nil {
+		return status.Error(codes.FailedPrecondition, "the first message must be a hello")
+	}
+	name := hello.GetName()
+	if !s.names[name] {
+		return status.Errorf(codes.PermissionDenied, "adapter name %q is not allowed by %q", name, env.ExternalAdapterNames)
+	}
+
+	slog.InfoContext(s.ctx, "external adapter connected", "name", name, "version", hello.GetVersion())
+	defer slog.InfoContext(s.ctx, "external adapter disconnected", "name", name)
+
+	if err := stream.Send(&adapterv1.PublisherMessage{Message: &adapterv1.PublisherMessage_Config{
+		Config: &adapterv1.Config{Settings: settings(name)},
+	}}); err != nil {
+		return err
+	}
+	if err := stream.Send(health(adapterv1.Health_STATUS_SERVING)); err != nil {
+		return err
+	}
+
+	msgs := make(chan *adapterv1.AdapterMessage)
+	errChan := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				errChan <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			// tell the adapter to stop sending, the stream is closed on return
+			_ = stream.Send(health(adapterv1.Health_STATUS_NOT_SERVING))
+			return nil
+		case err := <-errChan:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case msg := <-msgs:
+			item := msg.GetItem()
+			if item == nil {
+				return status.Error(codes.InvalidArgument, "only items are expected after the hello")
+			}
+			ri, err := toItem(name, item, time.Now())
+			if err != nil {
+				slog.WarnContext(s.ctx, "invalid item of external adapter", "name", name, "error", err)
+				continue
+			}
+			select {
+			case s.reportChan <- ri:
+			case <-s.ctx.Done():
+			}
+		}
+	}
+}
+
+func health(st adapterv1.Health_Status) *adapterv1.PublisherMessage {
+	return &adapterv1.PublisherMessage{Message: &adapterv1.PublisherMessage_Health{
+		Health: &adapterv1.Health{Status: st},
+	}}
+}
+
+// settings returns the EXTERNAL_ADAPTER_CONFIG_<NAME>_<KEY> variables of the adapter by key
+func settings(name string) map[string]string {
+	prefix := env.ExternalAdapterConfigPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	s := map[string]string{}
+	for _, e := range os.Environ() {
+		if key, value, ok := strings.Cut(e, "="); ok {
+			if k, ok := strings.CutPrefix(key, prefix); ok && k != "" {
+				s[k] = value
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	adapterv1 "github.com/bakito/policy-report-publisher/api/adapter/v1"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func toItem(adapter string, item *adapterv1.Item, received time.Time) (*report.Item, error) {
+	r := item.GetResult()
+	if item.GetNamespace() == "" {
+		return nil, errors.New("namespace must not be empty")
+	}
+	if r.GetPolicy() == "" || r.GetRule() == "" {
+		return nil, fmt.Errorf("policy %q and rule %q must not be empty", r.GetPolicy(), r.GetRule())
+	}
+
+	severity, err := report.ParseSeverity(r.GetSeverity(), report.DefaultSeverity)
+	if err != nil {
+		return nil, err
+	}
+	result, err := report.ParseResult(r.GetResult(), report.DefaultResult)
+	if err != nil {
+		return nil, err
+	}
+	source := r.GetSource()
+	if source == "" {
+		source = adapter
+	}
+	ts := received
+	if r.GetTimestamp() != nil {
+		ts = r.GetTimestamp().AsTime()
+	}
+
+	properties := map[string]string{}
+	maps.Copy(properties, r.GetProperties())
+	properties[report.PropertyCreated] = ts.Format(time.RFC3339)
+	properties[report.PropertyUpdated] = ts.Format(time.RFC3339)
+	properties["adapter"] = adapter
+
+	pr := prv1alpha2.PolicyReportResult{
+		Category: r.GetCategory(),
+		Message:  r.GetMessage(),
+
+		Severity: severity,
+		Policy:   r.GetPolicy(),
+		Rule:     r.GetRule(),
+		Result:   result,
+		Scored:   report.Scored(result),
+		Source:   source,
+		Timestamp: metav1.Timestamp{
+			Seconds: ts.Unix(),
+			Nanos:   int32(ts.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: properties,
+	}
+
+	if r.Scored != nil {
+		pr.Scored = r.GetScored()
+	}
+
+	if item.GetPod() != "" {
+		return report.ItemFor(adapter, item.GetNamespace(), item.GetPod(), pr, item), nil
+	}
+	return report.NamespaceItemFor(adapter, item.GetNamespace(), pr, item), nil
+}
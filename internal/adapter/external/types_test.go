@@ -0,0 +1,123 @@
+package external
+
+import (
+	"testing"
+	"time"
+
+	adapterv1 "github.com/bakito/policy-report-publisher/api/adapter/v1"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToItem(t *testing.T) {
+	received := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	sent := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		item         *adapterv1.Item
+		wantErr      bool
+		wantPod      string
+		wantSeverity prv1alpha2.PolicySeverity
+		wantResult   prv1alpha2.PolicyResult
+		wantScored   bool
+		wantSource   string
+		wantCreated  string
+	}{
+		{
+			name: "pod item",
+			item: &adapterv1.Item{Namespace: "shop", Pod: "backend-7d9f", Result: &adapterv1.Result{
+				Policy: "no-root", Rule: "runAsNonRoot", Severity: "high", Result: "warn", Source: "Scanner",
+				Timestamp: timestamppb.New(sent), Properties: map[string]string{"image": "nginx"},
+			}},
+			wantPod:      "backend-7d9f",
+			wantSeverity: "high",
+			wantResult:   "warn",
+			wantScored:   false,
+			wantSource:   "Scanner",
+			wantCreated:  "2026-10-19T10:00:00Z",
+		},
+		{
+			name:         "namespace item with defaults",
+			item:         &adapterv1.Item{Namespace: "shop", Result: &adapterv1.Result{Policy: "no-root", Rule: "runAsNonRoot"}},
+			wantSeverity: report.DefaultSeverity,
+			wantResult:   report.DefaultResult,
+			wantScored:   true,
+			wantSource:   "scanner",
+			wantCreated:  "2026-10-19T12:00:00Z",
+		},
+		{
+			name: "scored set by the adapter",
+			item: &adapterv1.Item{Namespace: "shop", Result: &adapterv1.Result{
+				Policy: "no-root", Rule: "runAsNonRoot", Result: "fail", Scored: proto.Bool(false),
+			}},
+			wantSeverity: report.DefaultSeverity,
+			wantResult:   "fail",
+			wantScored:   false,
+			wantSource:   "scanner",
+			wantCreated:  "2026-10-19T12:00:00Z",
+		},
+		{
+			name:    "missing namespace",
+			item:    &adapterv1.Item{Result: &adapterv1.Result{Policy: "no-root", Rule: "runAsNonRoot"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing rule",
+			item:    &adapterv1.Item{Namespace: "shop", Result: &adapterv1.Result{Policy: "no-root"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing result",
+			item:    &adapterv1.Item{Namespace: "shop"},
+			wantErr: true,
+		},
+		{
+			name: "invalid severity",
+			item: &adapterv1.Item{Namespace: "shop", Result: &adapterv1.Result{
+				Policy: "no-root", Rule: "runAsNonRoot", Severity: "severe",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid result",
+			item: &adapterv1.Item{Namespace: "shop", Result: &adapterv1.Result{
+				Policy: "no-root", Rule: "runAsNonRoot", Result: "denied",
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := toItem("scanner", tt.item, received)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if item.HandlerID() != "scanner" || item.Namespace != "shop" || item.Name != tt.wantPod ||
+				item.NamespaceScoped() != (tt.wantPod == "") {
+				t.Errorf("toItem() = %s/%s of %q, want shop/%s of %q", item.Namespace, item.Name, item.HandlerID(),
+					tt.wantPod, "scanner")
+			}
+			r := item.Result()
+			if r.Scored != tt.wantScored {
+				t.Errorf("toItem() scored = %v, want %v", r.Scored, tt.wantScored)
+			}
+			if r.Severity != tt.wantSeverity || r.Result != tt.wantResult || r.Source != tt.wantSource {
+				t.Errorf("toItem() severity, result, source = %q, %q, %q, want %q, %q, %q",
+					r.Severity, r.Result, r.Source, tt.wantSeverity, tt.wantResult, tt.wantSource)
+			}
+			if r.Properties["adapter"] != "scanner" || r.Properties["created"] != tt.wantCreated {
+				t.Errorf("toItem() properties = %v, want adapter %q and created %q", r.Properties, "scanner", tt.wantCreated)
+			}
+			for k, v := range tt.item.GetResult().GetProperties() {
+				if r.Properties[k] != v {
+					t.Errorf("property %q = %q, want %q", k, r.Properties[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package falco
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/webhook"
+)
+
+// Run receives the Falco alerts posted by the Falco http_output or the falcosidekick webhook output
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	address, ok := os.LookupEnv(env.FalcoWebhookAddress)
+	if !ok {
+		return fmt.Errorf("falco webhook address variable must %q be set", env.FalcoWebhookAddress)
+	}
+
+	t := webhook.TLS{
+		CertFile:     os.Getenv(env.FalcoWebhookTLSCert),
+		KeyFile:      os.Getenv(env.FalcoWebhookTLSKey),
+		ClientCAFile: os.Getenv(env.FalcoWebhookTLSClientCA),
+	}
+	token := os.Getenv(env.FalcoWebhookToken)
+	if token == "" && t.ClientCAFile == "" {
+		slog.WarnContext(ctx, "falco webhook accepts unauthenticated requests",
+			"token", env.FalcoWebhookToken, "client-ca", env.FalcoWebhookTLSClientCA)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		e, err := decodeEvent(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error unmarshalling event: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if item := e.toItem(); item != nil {
+			select {
+			case reportChan <- item:
+			case <-ctx.Done():
+				http.Error(w, "shutting down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return webhook.ServeTLS(ctx, "Falco", address, t, webhook.Authorize(token, mux))
+}
@@ -0,0 +1,122 @@
+package falco
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	reportSource = "Falco"
+
+	fieldNamespace = "k8s.ns.name"
+	fieldPod       = "k8s.pod.name"
+)
+
+// Event is a Falco alert as sent by the Falco http_output and falcosidekick
+type Event struct {
+	UUID         string         `json:"uuid"`
+	Output       string         `json:"output"`
+	Priority     string         `json:"priority"`
+	Rule         string         `json:"rule"`
+	Time         time.Time      `json:"time"`
+	OutputFields map[string]any `json:"output_fields"`
+	Source       string         `json:"source"`
+	Tags         []string       `json:"tags"`
+	Hostname     string         `json:"hostname"`
+}
+
+// decodeEvent decodes an alert, the numbers of the output fields are kept as sent (e.g. 1234567 instead of 1.234567e+06)
+func decodeEvent(r io.Reader) (*Event, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	e := &Event{}
+	if err := d.Decode(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e Event) toItem() *report.Item {
+	namespace := e.field(fieldNamespace)
+	pod := e.field(fieldPod)
+	if namespace == "" || pod == "" {
+		// alerts of the host or of non kubernetes containers
+		return nil
+	}
+
+	pr := prv1alpha2.PolicyReportResult{
+		Category: e.Source,
+		Message:  e.Output,
+
+		Severity: e.resultSeverity(),
+		Policy:   e.Rule,
+		Rule:     e.Rule,
+		Result:   "fail",
+		Scored:   report.Scored("fail"),
+		Source:   reportSource,
+		Timestamp: metav1.Timestamp{
+			Seconds: e.Time.Unix(),
+			Nanos:   int32(e.Time.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: map[string]string{
+			report.PropertyCreated: e.UpdatedTimeRFC3339(),
+			report.PropertyUpdated: e.UpdatedTimeRFC3339(),
+			"priority":             e.Priority,
+			"tags":                 strings.Join(e.Tags, ","),
+			"hostname":             e.Hostname,
+		},
+	}
+
+	for k := range e.OutputFields {
+		if k != fieldNamespace && k != fieldPod {
+			if v := e.field(k); v != "" {
+				pr.Properties[k] = v
+			}
+		}
+	}
+
+	return report.ItemFor("falco", namespace, pod, pr, &e)
+}
+
+func (e Event) field(name string) string {
+	v, ok := e.OutputFields[name]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (e Event) resultSeverity() prv1alpha2.PolicySeverity {
+	// Falco: priority: Emergency, Alert, Critical, Error, Warning, Notice, Informational, Debug
+
+	// PolicySeverity has one of the following values:
+	// - critical
+	// - high
+	// - low
+	// - medium
+	// - info
+
+	switch strings.ToLower(e.Priority) {
+	case "emergency", "alert", "critical":
+		return "critical"
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	case "notice":
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+func (e Event) UpdatedTimeRFC3339() string {
+	return e.Time.Format(time.RFC3339)
+}
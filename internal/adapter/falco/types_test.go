@@ -0,0 +1,113 @@
+package falco
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+func TestEventToItem(t *testing.T) {
+	e, err := decodeEvent(strings.NewReader(`{
+  "uuid": "d9e5c6a1",
+  "output": "Shell spawned in a container",
+  "priority": "Warning",
+  "rule": "Terminal shell in container",
+  "time": "2024-05-01T10:00:00.5Z",
+  "source": "syscall",
+  "tags": ["container", "shell"],
+  "hostname": "node-1",
+  "output_fields": {
+    "k8s.ns.name": "default",
+    "k8s.pod.name": "nginx",
+    "proc.pid": 1234567,
+    "proc.cmdline": "sh -c id",
+    "evt.rawres": -1,
+    "fd.num": 3.5,
+    "container.privileged": false,
+    "user.loginname": null
+  }
+}`))
+	if err != nil {
+		t.Fatalf("decodeEvent() error = %v", err)
+	}
+
+	item := e.toItem()
+	if item == nil {
+		t.Fatal("toItem() = nil")
+	}
+	if item.Namespace != "default" || item.Name != "nginx" {
+		t.Errorf("item = %s/%s, want default/nginx", item.Namespace, item.Name)
+	}
+	r := item.Result()
+	if r.Policy != "Terminal shell in container" || r.Rule != "Terminal shell in container" {
+		t.Errorf("policy, rule = %q, %q, want the rule", r.Policy, r.Rule)
+	}
+	if r.Severity != "medium" || r.Result != "fail" || r.Category != "syscall" {
+		t.Errorf("severity, result, category = %q, %q, %q, want medium, fail, syscall",
+			r.Severity, r.Result, r.Category)
+	}
+	if r.Timestamp.Seconds != 1714557600 || r.Timestamp.Nanos != 500000000 {
+		t.Errorf("timestamp = %v, want 1714557600.5", r.Timestamp)
+	}
+
+	want := map[string]string{
+		"created":              "2024-05-01T10:00:00Z",
+		"updated":              "2024-05-01T10:00:00Z",
+		"priority":             "Warning",
+		"tags":                 "container,shell",
+		"hostname":             "node-1",
+		"proc.pid":             "1234567",
+		"proc.cmdline":         "sh -c id",
+		"evt.rawres":           "-1",
+		"fd.num":               "3.5",
+		"container.privileged": "false",
+	}
+	if !reflect.DeepEqual(r.Properties, want) {
+		t.Errorf("properties = %v, want %v", r.Properties, want)
+	}
+}
+
+func TestEventToItemWithoutPod(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]any
+	}{
+		{name: "host", fields: map[string]any{"proc.pid": 1}},
+		{name: "namespace only", fields: map[string]any{fieldNamespace: "default"}},
+		{name: "no fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if item := (Event{Rule: "rule", OutputFields: tt.fields}).toItem(); item != nil {
+				t.Errorf("toItem() = %v, want nil", item)
+			}
+		})
+	}
+}
+
+func TestEventResultSeverity(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     prv1alpha2.PolicySeverity
+	}{
+		{priority: "Emergency", want: "critical"},
+		{priority: "Alert", want: "critical"},
+		{priority: "Critical", want: "critical"},
+		{priority: "Error", want: "high"},
+		{priority: "Warning", want: "medium"},
+		{priority: "Notice", want: "low"},
+		{priority: "Informational", want: "info"},
+		{priority: "Debug", want: "info"},
+		{priority: "warning", want: "medium"},
+		{priority: "", want: "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.priority, func(t *testing.T) {
+			if got := (Event{Priority: tt.priority}).resultSeverity(); got != tt.want {
+				t.Errorf("resultSeverity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
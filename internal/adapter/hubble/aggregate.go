@@ -0,0 +1,133 @@
+package hubble
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+)
+
+const (
+	defaultPrefixV4      = 24
+	prefixV6             = 64
+	defaultEphemeralPort = "32768-65535"
+
+	labelWorld = "reserved:world"
+)
+
+// reservedNames are the names of the reserved identities used if no better name exists, they match the cilium entities
+var reservedNames = map[string]string{
+	"reserved:kube-apiserver": "kube-apiserver",
+	"reserved:host":           "host",
+	"reserved:remote-node":    "remote-node",
+	"reserved:health":         "health",
+	"reserved:ingress":        "ingress",
+}
+
+// aggregator collapses world addresses and ephemeral ports, so the rules stay bounded
+type aggregator struct {
+	cidrs    []namedCIDR
+	prefixV4 int
+	// ephemeralFrom and ephemeralTo is the range of ephemeral ports, 0 if disabled
+	ephemeralFrom, ephemeralTo uint32
+}
+
+type namedCIDR struct {
+	name   string
+	prefix netip.Prefix
+}
+
+func newAggregator() (*aggregator, error) {
+	a := &aggregator{}
+	for _, c := range env.List(env.HubbleAggregateCIDRs, nil) {
+		name, cidr, ok := strings.Cut(c, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid named cidr %q in %q, must be <name>=<cidr>", c, env.HubbleAggregateCIDRs)
+		}
+		p, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr of %q in %q: %w", name, env.HubbleAggregateCIDRs, err)
+		}
+		a.cidrs = append(a.cidrs, namedCIDR{name: strings.TrimSpace(name), prefix: p.Masked()})
+	}
+	// the most specific cidr wins
+	slices.SortStableFunc(a.cidrs, func(x, y namedCIDR) int { return y.prefix.Bits() - x.prefix.Bits() })
+
+	var err error
+	if a.prefixV4, err = env.Int(env.HubbleAggregatePrefix, defaultPrefixV4); err != nil {
+		return nil, err
+	}
+	if a.prefixV4 < 0 || a.prefixV4 > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d in %q", a.prefixV4, env.HubbleAggregatePrefix)
+	}
+
+	if ports := env.String(env.HubbleEphemeralPorts, defaultEphemeralPort); ports != "none" {
+		if a.ephemeralFrom, a.ephemeralTo, err = parsePortRange(ports); err != nil {
+			return nil, fmt.Errorf("invalid port range in %q: %w", env.HubbleEphemeralPorts, err)
+		}
+	}
+	return a, nil
+}
+
+func parsePortRange(ports string) (uint32, uint32, error) {
+	from, to, ok := strings.Cut(ports, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q must be <from>-<to>", ports)
+	}
+	f, err := strconv.ParseUint(from, 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	t, err := strconv.ParseUint(to, 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	if f > t {
+		return 0, 0, fmt.Errorf("%q is not ascending", ports)
+	}
+	return uint32(f), uint32(t), nil
+}
+
+// name returns the name of a destination address: the named cidr, the reserved identity or the network of the address
+func (a *aggregator) name(ip string, labels []string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	for _, c := range a.cidrs {
+		if c.prefix.Contains(addr) {
+			return c.name
+		}
+	}
+	for _, l := range labels {
+		if name, ok := reservedNames[l]; ok {
+			return name
+		}
+	}
+
+	bits := prefixV6
+	if addr.Is4() {
+		bits = a.prefixV4
+	}
+	if bits > 0 {
+		if p, err := addr.Prefix(bits); err == nil {
+			return p.String()
+		}
+	}
+	if slices.Contains(labels, labelWorld) {
+		return "world"
+	}
+	return ""
+}
+
+// port returns the destination port, or the range if the port is ephemeral
+func (a *aggregator) port(port uint32) string {
+	if a != nil && a.ephemeralTo > 0 && port >= a.ephemeralFrom && port <= a.ephemeralTo {
+		return fmt.Sprintf("%d-%d", a.ephemeralFrom, a.ephemeralTo)
+	}
+	return strconv.FormatUint(uint64(port), 10)
+}
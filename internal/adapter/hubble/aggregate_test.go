@@ -0,0 +1,122 @@
+package hubble
+
+import (
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+)
+
+func TestNewAggregator(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   string
+		prefix  string
+		ports   string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "named cidrs", cidrs: "aws=52.0.0.0/8, office = 192.0.2.0/24"},
+		{name: "cidr without name", cidrs: "52.0.0.0/8", wantErr: true},
+		{name: "invalid cidr", cidrs: "aws=52.0.0.0/33", wantErr: true},
+		{name: "prefix disabled", prefix: "0"},
+		{name: "prefix too long", prefix: "33", wantErr: true},
+		{name: "invalid prefix", prefix: "x", wantErr: true},
+		{name: "ephemeral ports disabled", ports: "none"},
+		{name: "invalid port range", ports: "1024", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(env.HubbleAggregateCIDRs, tt.cidrs)
+			t.Setenv(env.HubbleAggregatePrefix, tt.prefix)
+			t.Setenv(env.HubbleEphemeralPorts, tt.ports)
+			if _, err := newAggregator(); (err != nil) != tt.wantErr {
+				t.Errorf("newAggregator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		ports    string
+		wantFrom uint32
+		wantTo   uint32
+		wantErr  bool
+	}{
+		{ports: "32768-65535", wantFrom: 32768, wantTo: 65535},
+		{ports: "1024-1024", wantFrom: 1024, wantTo: 1024},
+		{ports: "1024", wantErr: true},
+		{ports: "2048-1024", wantErr: true},
+		{ports: "1024-65536", wantErr: true},
+		{ports: "a-b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			from, to, err := parsePortRange(tt.ports)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePortRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("parsePortRange() = (%d, %d), want (%d, %d)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestAggregatorName(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		ip     string
+		labels []string
+		want   string
+	}{
+		{name: "named cidr", ip: "52.1.2.3", want: "aws"},
+		{name: "most specific named cidr", ip: "52.10.0.1", want: "aws-eu"},
+		{name: "named cidr wins over the identity", ip: "52.1.2.3", labels: []string{"reserved:kube-apiserver"}, want: "aws"},
+		{name: "reserved identity", ip: "10.0.0.1", labels: []string{"reserved:kube-apiserver"}, want: "kube-apiserver"},
+		{name: "ipv4 network", ip: "8.8.8.8", labels: []string{labelWorld}, want: "8.8.8.0/24"},
+		{name: "ipv4 mapped ipv6 address", ip: "::ffff:8.8.8.8", want: "8.8.8.0/24"},
+		{name: "ipv6 network", ip: "2001:db8::1", want: "2001:db8::/64"},
+		{name: "world without prefix", prefix: "0", ip: "8.8.8.8", labels: []string{labelWorld}, want: "world"},
+		{name: "no prefix and not world", prefix: "0", ip: "8.8.8.8"},
+		{name: "invalid address", ip: "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(env.HubbleAggregateCIDRs, "aws=52.0.0.0/8,aws-eu=52.10.0.0/16")
+			t.Setenv(env.HubbleAggregatePrefix, tt.prefix)
+			a, err := newAggregator()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.name(tt.ip, tt.labels); got != tt.want {
+				t.Errorf("name(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregatorPort(t *testing.T) {
+	a := &aggregator{ephemeralFrom: 32768, ephemeralTo: 65535}
+	tests := []struct {
+		name string
+		a    *aggregator
+		port uint32
+		want string
+	}{
+		{name: "well-known port", a: a, port: 443, want: "443"},
+		{name: "first ephemeral port", a: a, port: 32768, want: "32768-65535"},
+		{name: "ephemeral port", a: a, port: 40000, want: "32768-65535"},
+		{name: "port below the range", a: a, port: 32767, want: "32767"},
+		{name: "ephemeral ports disabled", a: &aggregator{}, port: 40000, want: "40000"},
+		{name: "aggregation disabled", port: 40000, want: "40000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.port(tt.port); got != tt.want {
+				t.Errorf("port(%d) = %q, want %q", tt.port, got, tt.want)
+			}
+		})
+	}
+}
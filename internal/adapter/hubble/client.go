@@ -3,14 +3,18 @@ package hubble
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/instance"
 	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/suggest"
 	"github.com/cilium/cilium/api/v1/flow"
 	observerpb "github.com/cilium/cilium/api/v1/observer"
 	"github.com/cilium/cilium/hubble/pkg/defaults"
@@ -21,39 +25,133 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 )
 
 func Run(ctx context.Context, reportChan chan *report.Item) error {
-	client, cleanup, err := newClient()
+	instances, err := instance.Parse(env.HubbleServiceName)
+	if err != nil {
+		return err
+	}
+
+	c, err := newConverter(ctx)
+	if err != nil {
+		return err
+	}
+
+	return instance.Run(ctx, instances, func(ctx context.Context, i instance.Instance) error {
+		return runInstance(ctx, c, i, reportChan)
+	})
+}
+
+func runInstance(ctx context.Context, c *converter, i instance.Instance, reportChan chan *report.Item) error {
+	client, cleanup, err := newClient(i)
 	if err != nil {
 		return err
 	}
 
 	defer func() { _ = cleanup() }()
 
+	// the converter is shared by the instances, only the instance differs
+	ic := *c
+	ic.instance = i
+
+	drops := &flow.FlowFilter{
+		TrafficDirection: []flow.TrafficDirection{flow.TrafficDirection_EGRESS},
+		Verdict:          []flow.Verdict{flow.Verdict_DROPPED},
+	}
 	req := &observerpb.GetFlowsRequest{
-		Follow: true,
-		Whitelist: []*flow.FlowFilter{
-			{
-				TrafficDirection: []flow.TrafficDirection{flow.TrafficDirection_EGRESS},
-				Verdict:          []flow.Verdict{flow.Verdict_DROPPED},
-			},
-		},
+		Follow:    true,
+		Whitelist: []*flow.FlowFilter{drops},
 	}
 
-	return getFlows(ctx, client, reportChan, req)
+	var answers *flow.FlowFilter
+	if c.names != nil {
+		answers = &flow.FlowFilter{
+			Protocol: []string{"dns"},
+			Verdict:  []flow.Verdict{flow.Verdict_FORWARDED},
+			Reply:    []bool{true},
+		}
+		req.Whitelist = append(req.Whitelist, answers)
+	}
+
+	// the pods of the namespaces, answers are sent to the pods
+	for _, ns := range env.List(i.Var(env.HubbleNamespaces), nil) {
+		drops.SourcePod = append(drops.SourcePod, ns+"/")
+		if answers != nil {
+			answers.DestinationPod = append(answers.DestinationPod, ns+"/")
+		}
+	}
+
+	return getFlows(ctx, client, &ic, reportChan, req)
 }
 
-func newClient() (observerpb.ObserverClient, func() error, error) {
-	var gRPC string
-	if val, ok := os.LookupEnv(env.HubbleServiceName); ok {
-		gRPC = val
-	} else {
+func newConverter(ctx context.Context) (*converter, error) {
+	infrastructure, err := parseInfrastructureMode(env.String(env.HubbleInfrastructureDrops, infrastructureReport))
+	if err != nil {
+		return nil, err
+	}
+	c := &converter{
+		defaultPolicy:   env.String(env.HubbleDefaultPolicy, defaultPolicy),
+		infrastructure:  infrastructure,
+		suggest:         env.String(env.HubbleSuggestPolicy, ""),
+		cluster:         env.String(env.HubbleClusterName, ""),
+		remotes:         map[string]bool{},
+		ignoredClusters: &sync.Map{},
+		nodeClusters:    &sync.Map{},
+	}
+	kubeconfigs, err := report.RemoteKubeconfigs()
+	if err != nil {
+		return nil, err
+	}
+	for cluster := range kubeconfigs {
+		c.remotes[cluster] = true
+	}
+	if c.suggest != "" && c.suggest != suggest.KindCilium && c.suggest != suggest.KindKubernetes {
+		return nil, fmt.Errorf("unknown policy kind %q in %q, must be one of %q, %q",
+			c.suggest, env.HubbleSuggestPolicy, suggest.KindCilium, suggest.KindKubernetes)
+	}
+	var config *rest.Config
+	if env.Active(env.HubblePolicyLabels) || env.Active(env.HubbleServices) {
+		if config, err = report.RestConfig(""); err != nil {
+			return nil, err
+		}
+	}
+	if env.Active(env.HubblePolicyLabels) {
+		dc, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		c.policies = &policyResolver{client: dc, cache: map[string]cachedLabels{}}
+	}
+	if env.Active(env.HubbleFQDNCache) {
+		minTTL, err := env.Duration(env.HubbleFQDNCacheMinTTL, time.Minute)
+		if err != nil {
+			return nil, err
+		}
+		c.names = newFQDNCache(minTTL)
+	}
+	if env.Active(env.HubbleServices) {
+		if c.services, err = newServiceResolver(ctx, config); err != nil {
+			return nil, err
+		}
+	}
+	if env.Active(env.HubbleAggregate) {
+		if c.aggregate, err = newAggregator(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func newClient(i instance.Instance) (observerpb.ObserverClient, func() error, error) {
+	if i.Address == "" {
 		return nil, nil, fmt.Errorf("hubble service name variable must %q be set", env.HubbleServiceName)
 	}
 
 	// read flows from a hubble server
-	hubbleConn, err := newConn(gRPC)
+	hubbleConn, err := newConn(i.Address, i)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -64,16 +162,17 @@ func newClient() (observerpb.ObserverClient, func() error, error) {
 }
 
 // New creates a new gRPC client connection to the target.
-func newConn(target string) (*grpc.ClientConn, error) {
+func newConn(target string, i instance.Instance) (*grpc.ClientConn, error) {
 	var creds credentials.TransportCredentials
 
-	if env.Active(env.HubbleInsecure) {
+	if env.Active(i.Var(env.HubbleInsecure)) {
 		creds = insecure.NewCredentials()
 	} else {
-		tlsConfig := tls.Config{
-			InsecureSkipVerify: true, // #nosec G402
+		tlsConfig, err := newTLSConfig(i)
+		if err != nil {
+			return nil, err
 		}
-		creds = credentials.NewTLS(&tlsConfig)
+		creds = credentials.NewTLS(tlsConfig)
 	}
 	t := strings.TrimPrefix(target, defaults.TargetTLSPrefix)
 	conn, err := grpc.NewClient(t,
@@ -85,7 +184,32 @@ func newConn(target string) (*grpc.ClientConn, error) {
 	return conn, nil
 }
 
-func getFlows(ctx context.Context, client observerpb.ObserverClient, reportChan chan *report.Item, req *observerpb.GetFlowsRequest) error {
+// newTLSConfig verifies the server certificate if a CA is configured
+func newTLSConfig(i instance.Instance) (*tls.Config, error) {
+	ca := env.String(i.Var(env.HubbleTLSCA), "")
+	if ca == "" {
+		return &tls.Config{
+			InsecureSkipVerify: true, // #nosec G402
+		}, nil
+	}
+	pem, err := os.ReadFile(ca)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the hubble CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in the hubble CA %q", ca)
+	}
+	return &tls.Config{
+		RootCAs:    pool,
+		ServerName: env.String(i.Var(env.HubbleTLSServerName), ""),
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+func getFlows(ctx context.Context, client observerpb.ObserverClient, c *converter, reportChan chan *report.Item,
+	req *observerpb.GetFlowsRequest,
+) error {
 	b, err := client.GetFlows(ctx, req)
 	if err != nil {
 		return err
@@ -106,10 +230,16 @@ func getFlows(ctx context.Context, client observerpb.ObserverClient, reportChan
 
 		switch r := resp.GetResponseTypes().(type) {
 		case *observerpb.GetFlowsResponse_Flow:
-			if !ignoreFlow(r.Flow) {
-				item := toItem(r.Flow)
+			if c.names != nil && isDNSAnswer(r.Flow) {
+				c.names.observe(r.Flow)
+			} else if !ignoreFlow(r.Flow) {
+				item := c.toItem(ctx, r.Flow)
 				if item != nil {
-					reportChan <- item
+					select {
+					case reportChan <- item:
+					case <-ctx.Done():
+						return nil
+					}
 				}
 			}
 		}
@@ -117,6 +247,14 @@ func getFlows(ctx context.Context, client observerpb.ObserverClient, reportChan
 }
 
 func ignoreFlow(f *flow.Flow) bool {
-	return f == nil || f.Source == nil || f.Source.PodName == "" ||
-		f.L4 == nil || (f.L4.GetTCP() == nil && f.L4.GetICMPv4() == nil)
+	if f == nil || f.Source == nil || f.Source.PodName == "" {
+		return true
+	}
+	if l7 := f.GetL7(); l7.GetDns() != nil || l7.GetHttp() != nil || l7.GetKafka() != nil {
+		return false
+	}
+	if _, _, ok := l4Port(f); ok {
+		return false
+	}
+	return f.GetL4().GetICMPv4() == nil
 }
@@ -0,0 +1,162 @@
+package hubble
+
+import (
+	"fmt"
+
+	"github.com/cilium/cilium/api/v1/flow"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+const (
+	// infrastructureIgnore drops the results of infrastructure drops
+	infrastructureIgnore = "ignore"
+	// infrastructureReport publishes the results of infrastructure drops in the report of the namespace
+	infrastructureReport = "report"
+
+	infrastructureSource = "Cilium Infrastructure Drop"
+)
+
+// dropClass classifies a drop reason
+type dropClass struct {
+	category string
+	severity prv1alpha2.PolicySeverity
+	// policy is true if the drop is a policy violation, other drops are caused by the infrastructure
+	policy bool
+}
+
+var (
+	// policyDrop is the class of the L7 denials, they have no drop reason
+	policyDrop  = dropClass{category: "Policy", severity: "high", policy: true}
+	unknownDrop = dropClass{category: "Unknown", severity: "medium", policy: true}
+
+	dropClasses = classes(map[dropClass][]flow.DropReason{
+		policyDrop: {
+			flow.DropReason_POLICY_DENIED,
+			flow.DropReason_POLICY_DENY,
+		},
+		{category: "Authentication", severity: "high", policy: true}: {
+			flow.DropReason_AUTH_REQUIRED,
+		},
+		{category: "Encryption", severity: "high", policy: true}: {
+			flow.DropReason_UNENCRYPTED_TRAFFIC,
+		},
+		{category: "Source Validation", severity: "medium", policy: true}: {
+			flow.DropReason_INVALID_SOURCE_MAC,
+			flow.DropReason_INVALID_SOURCE_IP,
+			flow.DropReason_DENIED_BY_LB_SRC_RANGE_CHECK,
+			flow.DropReason_FORBIDDEN_ICMPV6_MESSAGE,
+		},
+		{category: "Connection Tracking", severity: "low"}: {
+			flow.DropReason_CT_TRUNCATED_OR_INVALID_HEADER,
+			flow.DropReason_CT_MISSING_TCP_ACK_FLAG,
+			flow.DropReason_CT_UNKNOWN_L4_PROTOCOL,
+			flow.DropReason_CT_CANNOT_CREATE_ENTRY_FROM_PACKET,
+			flow.DropReason_CT_MAP_INSERTION_FAILED,
+			flow.DropReason_UNKNOWN_CONNECTION_TRACKING_STATE,
+			flow.DropReason_CT_NO_MAP_FOUND,
+		},
+		{category: "Routing", severity: "medium"}: {
+			flow.DropReason_UNKNOWN_L3_TARGET_ADDRESS,
+			flow.DropReason_STALE_OR_UNROUTABLE_IP,
+			flow.DropReason_NO_MATCHING_LOCAL_CONTAINER_FOUND,
+			flow.DropReason_SERVICE_BACKEND_NOT_FOUND,
+			flow.DropReason_NO_TUNNEL_OR_ENCAPSULATION_ENDPOINT,
+			flow.DropReason_LOCAL_HOST_IS_UNREACHABLE,
+			flow.DropReason_FIB_LOOKUP_FAILED,
+			flow.DropReason_INVALID_VNI,
+			flow.DropReason_NO_SID,
+			flow.DropReason_MISSING_SRV6_STATE,
+			flow.DropReason_NO_EGRESS_GATEWAY,
+			flow.DropReason_NO_NODE_ID,
+			flow.DropReason_DROP_NO_EGRESS_IP,
+			flow.DropReason_INVALID_CLUSTER_ID,
+		},
+		{category: "NAT", severity: "low"}: {
+			flow.DropReason_NO_MAPPING_FOR_NAT_MASQUERADE,
+			flow.DropReason_UNSUPPORTED_PROTOCOL_FOR_NAT_MASQUERADE,
+			flow.DropReason_NAT_NOT_NEEDED,
+			flow.DropReason_IS_A_CLUSTERIP,
+			flow.DropReason_NAT46,
+			flow.DropReason_NAT64,
+			flow.DropReason_SNAT_NO_MAP_FOUND,
+		},
+		{category: "Packet", severity: "low"}: {
+			flow.DropReason_INVALID_DESTINATION_MAC,
+			flow.DropReason_INVALID_PACKET_DROPPED,
+			flow.DropReason_UNSUPPORTED_L3_PROTOCOL,
+			flow.DropReason_UNKNOWN_L4_PROTOCOL,
+			flow.DropReason_UNKNOWN_ICMPV4_CODE,
+			flow.DropReason_UNKNOWN_ICMPV4_TYPE,
+			flow.DropReason_UNKNOWN_ICMPV6_CODE,
+			flow.DropReason_UNKNOWN_ICMPV6_TYPE,
+			flow.DropReason_INVALID_GENEVE_OPTION,
+			flow.DropReason_INVALID_IPV6_EXTENSION_HEADER,
+			flow.DropReason_IP_FRAGMENTATION_NOT_SUPPORTED,
+			flow.DropReason_UNSUPPORTED_L2_PROTOCOL,
+			flow.DropReason_FIRST_LOGICAL_DATAGRAM_FRAGMENT_NOT_FOUND,
+			flow.DropReason_PROXY_REDIRECTION_NOT_SUPPORTED_FOR_PROTOCOL,
+			flow.DropReason_VLAN_FILTERED,
+			flow.DropReason_UNSUPPORTED_PROTOCOL_FOR_DSR_ENCAP,
+			flow.DropReason_TTL_EXCEEDED,
+		},
+		{category: "Datapath", severity: "medium"}: {
+			flow.DropReason_MISSED_TAIL_CALL,
+			flow.DropReason_ERROR_WRITING_TO_PACKET,
+			flow.DropReason_ERROR_RETRIEVING_TUNNEL_KEY,
+			flow.DropReason_ERROR_RETRIEVING_TUNNEL_OPTIONS,
+			flow.DropReason_ERROR_WHILE_CORRECTING_L3_CHECKSUM,
+			flow.DropReason_ERROR_WHILE_CORRECTING_L4_CHECKSUM,
+			flow.DropReason_FAILED_TO_INSERT_INTO_PROXYMAP,
+			flow.DropReason_NO_CONFIGURATION_AVAILABLE_TO_PERFORM_POLICY_DECISION,
+			flow.DropReason_ENCAPSULATION_TRAFFIC_IS_PROHIBITED,
+			flow.DropReason_INVALID_IDENTITY,
+			flow.DropReason_UNKNOWN_SENDER,
+			flow.DropReason_SOCKET_LOOKUP_FAILED,
+			flow.DropReason_SOCKET_ASSIGN_FAILED,
+			flow.DropReason_INVALID_TC_BUFFER,
+			flow.DropReason_DROP_HOST_NOT_READY,
+			flow.DropReason_DROP_EP_NOT_READY,
+			flow.DropReason_DROP_PUNT_PROXY,
+		},
+		{category: "Rate Limit", severity: "medium"}: {
+			flow.DropReason_REACHED_EDT_RATE_LIMITING_DROP_HORIZON,
+			flow.DropReason_DROP_RATE_LIMITED,
+		},
+		{category: "Multicast", severity: "info"}: {
+			flow.DropReason_IGMP_HANDLED,
+			flow.DropReason_IGMP_SUBSCRIBED,
+			flow.DropReason_MULTICAST_HANDLED,
+		},
+	})
+)
+
+func classes(byClass map[dropClass][]flow.DropReason) map[flow.DropReason]dropClass {
+	m := map[flow.DropReason]dropClass{}
+	for c, reasons := range byClass {
+		for _, r := range reasons {
+			m[r] = c
+		}
+	}
+	return m
+}
+
+// classify returns the class of the drop reason of a flow. L7 denials have no drop reason, they are policy violations.
+func classify(f *flow.Flow) dropClass {
+	if f.GetL7() != nil {
+		return policyDrop
+	}
+	if c, ok := dropClasses[f.GetDropReasonDesc()]; ok {
+		return c
+	}
+	return unknownDrop
+}
+
+func parseInfrastructureMode(mode string) (string, error) {
+	switch mode {
+	case infrastructureIgnore, infrastructureReport:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown infrastructure drop mode %q, must be one of %q, %q",
+			mode, infrastructureIgnore, infrastructureReport)
+	}
+}
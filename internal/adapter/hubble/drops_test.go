@@ -0,0 +1,87 @@
+package hubble
+
+import (
+	"testing"
+
+	"github.com/cilium/cilium/api/v1/flow"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name         string
+		flow         *flow.Flow
+		wantCategory string
+		wantPolicy   bool
+	}{
+		{
+			name:         "policy denied",
+			flow:         &flow.Flow{DropReasonDesc: flow.DropReason_POLICY_DENIED},
+			wantCategory: "Policy",
+			wantPolicy:   true,
+		},
+		{
+			name:         "L7 denial without drop reason",
+			flow:         &flow.Flow{L7: &flow.Layer7{Record: &flow.Layer7_Dns{Dns: &flow.DNS{Query: "example.com."}}}},
+			wantCategory: "Policy",
+			wantPolicy:   true,
+		},
+		{
+			name:         "authentication required",
+			flow:         &flow.Flow{DropReasonDesc: flow.DropReason_AUTH_REQUIRED},
+			wantCategory: "Authentication",
+			wantPolicy:   true,
+		},
+		{
+			name:         "connection tracking",
+			flow:         &flow.Flow{DropReasonDesc: flow.DropReason_CT_MAP_INSERTION_FAILED},
+			wantCategory: "Connection Tracking",
+		},
+		{
+			name:         "routing",
+			flow:         &flow.Flow{DropReasonDesc: flow.DropReason_FIB_LOOKUP_FAILED},
+			wantCategory: "Routing",
+		},
+		{
+			name:         "rate limit",
+			flow:         &flow.Flow{DropReasonDesc: flow.DropReason_DROP_RATE_LIMITED},
+			wantCategory: "Rate Limit",
+		},
+		{
+			name:         "unknown reason",
+			flow:         &flow.Flow{},
+			wantCategory: "Unknown",
+			wantPolicy:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(tt.flow)
+			if got.category != tt.wantCategory || got.policy != tt.wantPolicy {
+				t.Errorf("classify() = (%q, %v), want (%q, %v)", got.category, got.policy, tt.wantCategory, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestParseInfrastructureMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: infrastructureReport},
+		{mode: infrastructureIgnore},
+		{mode: "drop", wantErr: true},
+		{mode: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, err := parseInfrastructureMode(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseInfrastructureMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.mode {
+				t.Errorf("parseInfrastructureMode() = %q, want %q", got, tt.mode)
+			}
+		})
+	}
+}
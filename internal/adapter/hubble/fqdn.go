@@ -0,0 +1,124 @@
+package hubble
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/metrics"
+	"github.com/cilium/cilium/api/v1/flow"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	fqdnCacheSize  = 1024
+	fqdnCacheSweep = 5 * time.Minute
+
+	lookupHit  = "hit"
+	lookupMiss = "miss"
+)
+
+var (
+	fqdnLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "hubble_fqdn_cache_lookups",
+			Namespace: metrics.Namespace,
+			Help:      "The number of lookups of unnamed destinations in the FQDN cache of the Hubble adapter by result",
+		},
+		[]string{"result"},
+	)
+	// the adapter is started again for every leadership term
+	registerFQDNMetrics = sync.OnceFunc(func() { prometheus.MustRegister(fqdnLookups) })
+)
+
+// fqdnCache maps the addresses of the observed DNS answers to the queried names. The names are kept per pod,
+// as the same address may serve different names and only the names resolved by the pod itself are meaningful.
+type fqdnCache struct {
+	minTTL    time.Duration
+	mux       sync.Mutex
+	pods      map[string]map[string]cachedName
+	lastSweep time.Time
+}
+
+type cachedName struct {
+	fqdn    string
+	expires time.Time
+}
+
+func newFQDNCache(minTTL time.Duration) *fqdnCache {
+	registerFQDNMetrics()
+	return &fqdnCache{minTTL: minTTL, pods: map[string]map[string]cachedName{}, lastSweep: time.Now()}
+}
+
+// isDNSAnswer returns true if the flow is a forwarded DNS response
+func isDNSAnswer(f *flow.Flow) bool {
+	return f.GetVerdict() == flow.Verdict_FORWARDED && f.GetL7().GetType() == flow.L7FlowType_RESPONSE &&
+		f.GetL7().GetDns() != nil
+}
+
+// observe adds the addresses of a DNS answer. Responses are sent from the DNS server to the pod.
+func (c *fqdnCache) observe(f *flow.Flow) {
+	dns := f.GetL7().GetDns()
+	pod := f.GetDestination()
+	fqdn := strings.TrimSuffix(dns.GetQuery(), ".")
+	if pod.GetPodName() == "" || fqdn == "" || len(dns.GetIps()) == 0 {
+		return
+	}
+	// clients keep connections open beyond the TTL of the answer
+	ttl := max(time.Duration(dns.GetTtl())*time.Second, c.minTTL)
+
+	now := time.Now()
+	key := pod.GetNamespace() + "/" + pod.GetPodName()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if now.Sub(c.lastSweep) > fqdnCacheSweep {
+		c.sweep(now)
+	}
+	names, ok := c.pods[key]
+	if !ok {
+		names = map[string]cachedName{}
+		c.pods[key] = names
+	}
+	if len(names) >= fqdnCacheSize {
+		prune(names, now)
+	}
+	for _, ip := range dns.GetIps() {
+		if _, ok := names[ip]; ok || len(names) < fqdnCacheSize {
+			names[ip] = cachedName{fqdn: fqdn, expires: now.Add(ttl)}
+		}
+	}
+}
+
+// lookup returns the name resolved by the pod for the address
+func (c *fqdnCache) lookup(namespace, pod, ip string) (string, bool) {
+	c.mux.Lock()
+	n, ok := c.pods[namespace+"/"+pod][ip]
+	c.mux.Unlock()
+
+	if ok && time.Now().Before(n.expires) {
+		fqdnLookups.WithLabelValues(lookupHit).Inc()
+		return n.fqdn, true
+	}
+	fqdnLookups.WithLabelValues(lookupMiss).Inc()
+	return "", false
+}
+
+// sweep removes the expired names and the pods without names
+func (c *fqdnCache) sweep(now time.Time) {
+	for key, names := range c.pods {
+		prune(names, now)
+		if len(names) == 0 {
+			delete(c.pods, key)
+		}
+	}
+	c.lastSweep = now
+}
+
+func prune(names map[string]cachedName, now time.Time) {
+	for ip, n := range names {
+		if now.After(n.expires) {
+			delete(names, ip)
+		}
+	}
+}
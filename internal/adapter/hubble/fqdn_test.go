@@ -0,0 +1,102 @@
+package hubble
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cilium/cilium/api/v1/flow"
+)
+
+func TestIsDNSAnswer(t *testing.T) {
+	dns := &flow.Layer7_Dns{Dns: &flow.DNS{Query: "example.com."}}
+	tests := []struct {
+		name string
+		flow *flow.Flow
+		want bool
+	}{
+		{
+			name: "forwarded response",
+			flow: &flow.Flow{Verdict: flow.Verdict_FORWARDED, L7: &flow.Layer7{Type: flow.L7FlowType_RESPONSE, Record: dns}},
+			want: true,
+		},
+		{
+			name: "request",
+			flow: &flow.Flow{Verdict: flow.Verdict_FORWARDED, L7: &flow.Layer7{Type: flow.L7FlowType_REQUEST, Record: dns}},
+		},
+		{
+			name: "dropped",
+			flow: &flow.Flow{Verdict: flow.Verdict_DROPPED, L7: &flow.Layer7{Type: flow.L7FlowType_RESPONSE, Record: dns}},
+		},
+		{
+			name: "http response",
+			flow: &flow.Flow{Verdict: flow.Verdict_FORWARDED, L7: &flow.Layer7{Type: flow.L7FlowType_RESPONSE, Record: &flow.Layer7_Http{Http: &flow.HTTP{}}}},
+		},
+		{
+			name: "L4",
+			flow: &flow.Flow{Verdict: flow.Verdict_FORWARDED},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDNSAnswer(tt.flow); got != tt.want {
+				t.Errorf("isDNSAnswer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFQDNCacheLookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		pod       string
+		ip        string
+		want      string
+		wantOK    bool
+	}{
+		{name: "resolved by the pod", namespace: "shop", pod: "orders-1", ip: "203.0.113.10", want: "api.example.com", wantOK: true},
+		{name: "second address of the answer", namespace: "shop", pod: "orders-1", ip: "203.0.113.11", want: "api.example.com", wantOK: true},
+		{name: "resolved by another pod", namespace: "shop", pod: "orders-2", ip: "203.0.113.10"},
+		{name: "unknown address", namespace: "shop", pod: "orders-1", ip: "198.51.100.1"},
+		{name: "expired", namespace: "shop", pod: "orders-1", ip: "203.0.113.20"},
+		{name: "answer without pod", namespace: "", pod: "", ip: "203.0.113.30"},
+	}
+
+	c := newFQDNCache(time.Minute)
+	c.observe(dnsAnswer("shop", "orders-1", "api.example.com.", "203.0.113.10", "203.0.113.11"))
+	c.observe(dnsAnswer("shop", "orders-1", "old.example.com.", "203.0.113.20"))
+	c.observe(dnsAnswer("", "", "none.example.com.", "203.0.113.30"))
+	c.pods["shop/orders-1"]["203.0.113.20"] = cachedName{fqdn: "old.example.com", expires: time.Now().Add(-time.Second)}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.lookup(tt.namespace, tt.pod, tt.ip)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFQDNCacheSweep(t *testing.T) {
+	c := newFQDNCache(time.Minute)
+	c.observe(dnsAnswer("shop", "orders-1", "api.example.com.", "203.0.113.10"))
+	c.observe(dnsAnswer("shop", "orders-2", "api.example.com.", "203.0.113.10"))
+
+	c.sweep(time.Now().Add(2 * time.Minute))
+	if len(c.pods) != 0 {
+		t.Errorf("sweep() kept %d pods, want 0", len(c.pods))
+	}
+}
+
+// dnsAnswer returns the response of the DNS server to the pod
+func dnsAnswer(namespace, pod, query string, ips ...string) *flow.Flow {
+	return &flow.Flow{
+		Verdict:     flow.Verdict_FORWARDED,
+		Destination: &flow.Endpoint{Namespace: namespace, PodName: pod},
+		L7: &flow.Layer7{
+			Type:   flow.L7FlowType_RESPONSE,
+			Record: &flow.Layer7_Dns{Dns: &flow.DNS{Query: query, Ips: ips, Ttl: 30}},
+		},
+	}
+}
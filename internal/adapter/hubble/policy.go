@@ -0,0 +1,71 @@
+package hubble
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cilium/cilium/api/v1/flow"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	policyCacheTTL = 5 * time.Minute
+
+	propertyPolicyLabelPrefix = "policy-label/"
+)
+
+// policyResources are the resources of the policy kinds referenced by the flows
+var policyResources = map[string]schema.GroupVersionResource{
+	"CiliumNetworkPolicy":            {Group: "cilium.io", Version: "v2", Resource: "ciliumnetworkpolicies"},
+	"CiliumClusterwideNetworkPolicy": {Group: "cilium.io", Version: "v2", Resource: "ciliumclusterwidenetworkpolicies"},
+	"NetworkPolicy":                  {Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+}
+
+// +kubebuilder:rbac:groups=cilium.io,resources=ciliumnetworkpolicies;ciliumclusterwidenetworkpolicies,verbs=get
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get
+
+// policyResolver looks up the labels of the denying policies, the labels are cached as policies rarely change
+type policyResolver struct {
+	client dynamic.Interface
+	mux    sync.Mutex
+	cache  map[string]cachedLabels
+}
+
+type cachedLabels struct {
+	labels  map[string]string
+	expires time.Time
+}
+
+func (r *policyResolver) labels(ctx context.Context, p *flow.Policy) map[string]string {
+	gvr, ok := policyResources[p.GetKind()]
+	if !ok {
+		return nil
+	}
+	key := p.GetKind() + "/" + p.GetNamespace() + "/" + p.GetName()
+	now := time.Now()
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if c, ok := r.cache[key]; ok && now.Before(c.expires) {
+		return c.labels
+	}
+
+	var labels map[string]string
+	obj, err := r.client.Resource(gvr).Namespace(p.GetNamespace()).Get(ctx, p.GetName(), metav1.GetOptions{})
+	switch {
+	case err == nil:
+		labels = obj.GetLabels()
+	case errors.IsNotFound(err):
+	default:
+		slog.ErrorContext(ctx, "failed to get the denying policy", "kind", p.GetKind(),
+			"namespace", p.GetNamespace(), "name", p.GetName(), "error", err)
+		return nil
+	}
+	r.cache[key] = cachedLabels{labels: labels, expires: now.Add(policyCacheTTL)}
+	return labels
+}
@@ -0,0 +1,101 @@
+package hubble
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const addressIndex = "address"
+
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=list;watch
+
+// serviceResolver finds the service of a pod address by the endpoint slices of the services
+type serviceResolver struct {
+	slices   cache.Indexer
+	services cache.Indexer
+}
+
+func newServiceResolver(ctx context.Context, config *rest.Config) (*serviceResolver, error) {
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	factory := informers.NewSharedInformerFactory(cs, 0)
+	slices := factory.Discovery().V1().EndpointSlices().Informer()
+	if err := slices.AddIndexers(cache.Indexers{addressIndex: sliceAddresses}); err != nil {
+		return nil, err
+	}
+	services := factory.Core().V1().Services().Informer()
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), slices.HasSynced, services.HasSynced) {
+		return nil, errors.New("failed to sync the endpoint slices and services")
+	}
+	return &serviceResolver{slices: slices.GetIndexer(), services: services.GetIndexer()}, nil
+}
+
+func sliceAddresses(obj any) ([]string, error) {
+	s, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok || s.Labels[discoveryv1.LabelServiceName] == "" {
+		return nil, nil
+	}
+	var addresses []string
+	for _, e := range s.Endpoints {
+		addresses = append(addresses, e.Addresses...)
+	}
+	return addresses, nil
+}
+
+// service returns <namespace>/<service> of the address and the service port of the target port, the first service
+// by name if the address backs several services. Only services exposing the target port are considered, a port of 0
+// matches any service and returns no service port.
+func (r *serviceResolver) service(ip string, protocol corev1.Protocol, targetPort uint32) (string, uint32) {
+	objs, err := r.slices.ByIndex(addressIndex, ip)
+	if err != nil {
+		return "", 0
+	}
+	var service string
+	var servicePort uint32
+	for _, obj := range objs {
+		s := obj.(*discoveryv1.EndpointSlice)
+		name := s.Namespace + "/" + s.Labels[discoveryv1.LabelServiceName]
+		if service != "" && name >= service {
+			continue
+		}
+		if targetPort == 0 {
+			service = name
+			continue
+		}
+		if port, ok := r.servicePort(name, s, protocol, targetPort); ok {
+			service, servicePort = name, port
+		}
+	}
+	return service, servicePort
+}
+
+// servicePort maps the target port to the port of the service. The ports of an endpoint slice are the target ports,
+// they are named like the ports of the service.
+func (r *serviceResolver) servicePort(service string, s *discoveryv1.EndpointSlice, protocol corev1.Protocol, targetPort uint32) (uint32, bool) {
+	obj, ok, err := r.services.GetByKey(service)
+	if err != nil || !ok {
+		return 0, false
+	}
+	for _, sp := range s.Ports {
+		if sp.Port == nil || int64(*sp.Port) != int64(targetPort) || sp.Protocol == nil || *sp.Protocol != protocol || sp.Name == nil {
+			continue
+		}
+		for _, p := range obj.(*corev1.Service).Spec.Ports {
+			if p.Protocol == protocol && p.Name == *sp.Name {
+				return uint32(p.Port), true // #nosec G115 ports are positive
+			}
+		}
+	}
+	return 0, false
+}
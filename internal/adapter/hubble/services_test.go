@@ -0,0 +1,96 @@
+package hubble
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestServiceResolverService(t *testing.T) {
+	r := newTestServiceResolver(t,
+		[]*corev1.Service{
+			testService("shop", "orders", corev1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)}),
+			testService("shop", "orders-admin", corev1.ServicePort{Name: "", Port: 9000, TargetPort: intstr.FromInt32(9090)}),
+			testService("shop", "api", corev1.ServicePort{Name: "http", Port: 443, TargetPort: intstr.FromInt32(8080)}),
+		},
+		[]*discoveryv1.EndpointSlice{
+			testSlice("shop", "orders", "http", 8080, "10.0.0.1"),
+			testSlice("shop", "orders-admin", "", 9090, "10.0.0.1"),
+			testSlice("shop", "api", "http", 8080, "10.0.0.2"),
+			testSlice("shop", "orders", "http", 8080, "10.0.0.2"),
+			testSlice("shop", "deleted", "http", 8080, "10.0.0.4"),
+		},
+	)
+	tests := []struct {
+		name        string
+		ip          string
+		protocol    corev1.Protocol
+		port        uint32
+		want        string
+		wantSvcPort uint32
+	}{
+		{name: "target port mapped to the service port", ip: "10.0.0.1", protocol: corev1.ProtocolTCP, port: 8080, want: "shop/orders", wantSvcPort: 80},
+		{name: "unnamed port", ip: "10.0.0.1", protocol: corev1.ProtocolTCP, port: 9090, want: "shop/orders-admin", wantSvcPort: 9000},
+		{name: "port not exposed by a service", ip: "10.0.0.1", protocol: corev1.ProtocolTCP, port: 22},
+		{name: "other protocol", ip: "10.0.0.1", protocol: corev1.ProtocolUDP, port: 8080},
+		{name: "no port matches the first service by name", ip: "10.0.0.1", want: "shop/orders"},
+		{name: "first service by name", ip: "10.0.0.2", protocol: corev1.ProtocolTCP, port: 8080, want: "shop/api", wantSvcPort: 443},
+		{name: "slice of a deleted service", ip: "10.0.0.4", protocol: corev1.ProtocolTCP, port: 8080},
+		{name: "unknown address", ip: "10.0.0.3", protocol: corev1.ProtocolTCP, port: 8080},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, port := r.service(tt.ip, tt.protocol, tt.port)
+			if got != tt.want || port != tt.wantSvcPort {
+				t.Errorf("service() = (%q, %d), want (%q, %d)", got, port, tt.want, tt.wantSvcPort)
+			}
+		})
+	}
+}
+
+func newTestServiceResolver(t *testing.T, services []*corev1.Service, slices []*discoveryv1.EndpointSlice) *serviceResolver {
+	t.Helper()
+	r := &serviceResolver{
+		slices:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{addressIndex: sliceAddresses}),
+		services: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+	}
+	for _, s := range services {
+		if err := r.services.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range slices {
+		if err := r.slices.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func testService(namespace, name string, ports ...corev1.ServicePort) *corev1.Service {
+	for i := range ports {
+		ports[i].Protocol = corev1.ProtocolTCP
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.ServiceSpec{Ports: ports},
+	}
+}
+
+func testSlice(namespace, service, portName string, port int32, addresses ...string) *discoveryv1.EndpointSlice {
+	protocol := corev1.ProtocolTCP
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      service + "-" + strings.ReplaceAll(addresses[0], ".", "-"),
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		Endpoints: []discoveryv1.Endpoint{{Addresses: addresses}},
+		Ports:     []discoveryv1.EndpointPort{{Name: &portName, Port: &port, Protocol: &protocol}},
+	}
+}
@@ -0,0 +1,219 @@
+package hubble
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/suggest"
+	"github.com/cilium/cilium/api/v1/flow"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	k8sLabelPrefix = "k8s:"
+	namespaceLabel = "io.kubernetes.pod.namespace"
+)
+
+// volatileLabels are not used in the selectors of the suggested rules, they differ between the pods of a workload
+var volatileLabels = []string{
+	"io.cilium.k8s.",
+	"pod-template-hash",
+	"controller-revision-hash",
+	"statefulset.kubernetes.io/pod-name",
+	"apps.kubernetes.io/pod-index",
+	"batch.kubernetes.io/",
+}
+
+// peer is the original destination of a flow, before it is named
+type peer struct {
+	namespace string
+	labels    map[string]string
+	entity    string
+	fqdn      string
+	ip        string
+}
+
+func peerOf(f *flow.Flow) peer {
+	p := peer{ip: f.GetIP().GetDestination()}
+	if len(f.GetDestinationNames()) > 0 {
+		p.fqdn = f.GetDestinationNames()[0]
+	}
+	dest := f.GetDestination()
+	if dest.GetNamespace() != "" {
+		p.namespace = dest.GetNamespace()
+		p.labels = selectorLabels(dest.GetLabels())
+	}
+	for _, l := range dest.GetLabels() {
+		if e, ok := reservedNames[l]; ok {
+			p.entity = e
+		}
+	}
+	return p
+}
+
+// selectorLabels returns the stable kubernetes labels of an endpoint, without the namespace
+func selectorLabels(endpointLabels []string) map[string]string {
+	selector := map[string]string{}
+	for _, l := range endpointLabels {
+		k, v, ok := strings.Cut(strings.TrimPrefix(l, k8sLabelPrefix), "=")
+		if !ok || !strings.HasPrefix(l, k8sLabelPrefix) || k == namespaceLabel || isVolatile(k) {
+			continue
+		}
+		selector[k] = v
+	}
+	return selector
+}
+
+func isVolatile(label string) bool {
+	for _, v := range volatileLabels {
+		if strings.HasPrefix(label, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestedRule returns the YAML of the egress rule that would allow the flow, empty if the flow can not be allowed
+// by a minimal rule
+func (c *converter) suggestedRule(f *flow.Flow, p peer) (string, error) {
+	switch c.suggest {
+	case suggest.KindCilium:
+		if r, ok := ciliumRule(f, p); ok {
+			return suggest.Rule(r)
+		}
+	case suggest.KindKubernetes:
+		if r, ok := kubernetesRule(f, p); ok {
+			return suggest.Rule(r)
+		}
+	}
+	return "", nil
+}
+
+// ciliumRule returns the CiliumNetworkPolicy rule, false if the destination pod has no stable labels
+func ciliumRule(f *flow.Flow, p peer) (suggest.EgressRule, bool) {
+	var r suggest.EgressRule
+	switch {
+	case p.namespace != "":
+		// the rule would allow the whole namespace
+		if len(p.labels) == 0 {
+			return r, false
+		}
+		labels := map[string]string{namespaceLabel: p.namespace}
+		maps.Copy(labels, p.labels)
+		r.ToEndpoints = []suggest.EndpointSelector{{MatchLabels: labels}}
+	case p.entity != "":
+		r.ToEntities = []string{p.entity}
+	case p.fqdn != "":
+		r.ToFQDNs = []suggest.FQDNSelector{{MatchName: p.fqdn}}
+	case p.ip != "":
+		r.ToCIDR = []string{hostCIDR(p.ip)}
+	}
+
+	l7 := f.GetL7()
+	switch {
+	case f.GetL4().GetICMPv4() != nil:
+		r.ICMPs = []suggest.ICMPRule{{Fields: []suggest.ICMPField{{Family: "IPv4", Type: f.GetL4().GetICMPv4().GetType()}}}}
+	case l7.GetDns() != nil:
+		r.ToPorts = []suggest.PortRule{{
+			Ports: []suggest.PortProtocol{{Port: "53", Protocol: "ANY"}},
+			Rules: &suggest.L7Rules{DNS: []suggest.FQDNSelector{{MatchName: strings.TrimSuffix(l7.GetDns().GetQuery(), ".")}}},
+		}}
+	case l7.GetHttp() != nil:
+		pr, _ := portRule(f)
+		rule := suggest.HTTPRule{Method: l7.GetHttp().GetMethod()}
+		if u, err := url.Parse(l7.GetHttp().GetUrl()); err == nil {
+			rule.Path = strings.ReplaceAll(report.PathTemplate(u.Path), "{id}", "[^/]+")
+		}
+		pr.Rules = &suggest.L7Rules{HTTP: []suggest.HTTPRule{rule}}
+		r.ToPorts = []suggest.PortRule{pr}
+	case l7.GetKafka() != nil:
+		pr, _ := portRule(f)
+		pr.Rules = &suggest.L7Rules{Kafka: []suggest.KafkaRule{{APIKey: l7.GetKafka().GetApiKey(), Topic: l7.GetKafka().GetTopic()}}}
+		r.ToPorts = []suggest.PortRule{pr}
+	default:
+		if pr, ok := portRule(f); ok {
+			r.ToPorts = []suggest.PortRule{pr}
+		}
+	}
+	return r, true
+}
+
+func portRule(f *flow.Flow) (suggest.PortRule, bool) {
+	proto, port, ok := l4Port(f)
+	if !ok {
+		return suggest.PortRule{}, false
+	}
+	return suggest.PortRule{Ports: []suggest.PortProtocol{{
+		Port:     strconv.FormatUint(uint64(port), 10),
+		Protocol: string(proto),
+	}}}, true
+}
+
+// l4Port returns the protocol and destination port of a TCP, UDP or SCTP flow
+func l4Port(f *flow.Flow) (corev1.Protocol, uint32, bool) {
+	switch l4 := f.GetL4(); {
+	case l4.GetTCP() != nil:
+		return corev1.ProtocolTCP, l4.GetTCP().GetDestinationPort(), true
+	case l4.GetUDP() != nil:
+		return corev1.ProtocolUDP, l4.GetUDP().GetDestinationPort(), true
+	case l4.GetSCTP() != nil:
+		return corev1.ProtocolSCTP, l4.GetSCTP().GetDestinationPort(), true
+	}
+	return "", 0, false
+}
+
+// kubernetesRule returns the NetworkPolicy rule, NetworkPolicies have no names or L7 rules. False is returned for
+// ICMP flows, as NetworkPolicies can not select them, and if the destination pod has no stable labels.
+func kubernetesRule(f *flow.Flow, p peer) (networkingv1.NetworkPolicyEgressRule, bool) {
+	var r networkingv1.NetworkPolicyEgressRule
+	if f.GetL4().GetICMPv4() != nil || f.GetL4().GetICMPv6() != nil {
+		return r, false
+	}
+	switch {
+	case p.namespace != "":
+		// an empty pod selector would allow the whole namespace
+		if len(p.labels) == 0 {
+			return r, false
+		}
+		r.To = []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: p.namespace}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: p.labels},
+		}}
+	case p.ip != "":
+		r.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: hostCIDR(p.ip)}}}
+	}
+
+	switch {
+	case f.GetL7().GetDns() != nil:
+		for _, proto := range []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP} {
+			r.Ports = append(r.Ports, networkPolicyPort(proto, 53))
+		}
+	default:
+		if proto, port, ok := l4Port(f); ok {
+			r.Ports = []networkingv1.NetworkPolicyPort{networkPolicyPort(proto, port)}
+		}
+	}
+	return r, true
+}
+
+func networkPolicyPort(proto corev1.Protocol, port uint32) networkingv1.NetworkPolicyPort {
+	p := intstr.FromInt32(int32(port)) // #nosec G115 ports are within int32
+	return networkingv1.NetworkPolicyPort{Protocol: &proto, Port: &p}
+}
+
+// hostCIDR returns the cidr of a single address
+func hostCIDR(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	return fmt.Sprintf("%s/%d", addr.String(), addr.BitLen())
+}
@@ -0,0 +1,174 @@
+package hubble
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/suggest"
+	"github.com/cilium/cilium/api/v1/flow"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	backend = peer{namespace: "shop", labels: map[string]string{"app": "backend"}}
+	world   = peer{ip: "203.0.113.10"}
+)
+
+func TestKubernetesRule(t *testing.T) {
+	backendPeer := []networkingv1.NetworkPolicyPeer{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "shop"}},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "backend"}},
+	}}
+	worldPeer := []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "203.0.113.10/32"}}}
+
+	tests := []struct {
+		name   string
+		flow   *flow.Flow
+		peer   peer
+		want   networkingv1.NetworkPolicyEgressRule
+		wantOK bool
+	}{
+		{
+			name:   "tcp to pod",
+			flow:   tcpFlow(8080),
+			peer:   backend,
+			want:   networkingv1.NetworkPolicyEgressRule{To: backendPeer, Ports: ports(corev1.ProtocolTCP, 8080)},
+			wantOK: true,
+		},
+		{
+			name:   "udp to ip",
+			flow:   &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_UDP{UDP: &flow.UDP{DestinationPort: 123}}}},
+			peer:   world,
+			want:   networkingv1.NetworkPolicyEgressRule{To: worldPeer, Ports: ports(corev1.ProtocolUDP, 123)},
+			wantOK: true,
+		},
+		{
+			name:   "sctp to pod",
+			flow:   &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_SCTP{SCTP: &flow.SCTP{DestinationPort: 3868}}}},
+			peer:   backend,
+			want:   networkingv1.NetworkPolicyEgressRule{To: backendPeer, Ports: ports(corev1.ProtocolSCTP, 3868)},
+			wantOK: true,
+		},
+		{
+			name: "dns",
+			flow: &flow.Flow{L7: &flow.Layer7{Record: &flow.Layer7_Dns{Dns: &flow.DNS{Query: "example.com."}}}},
+			peer: world,
+			want: networkingv1.NetworkPolicyEgressRule{
+				To:    worldPeer,
+				Ports: append(ports(corev1.ProtocolUDP, 53), ports(corev1.ProtocolTCP, 53)...),
+			},
+			wantOK: true,
+		},
+		{
+			name: "icmpv4",
+			flow: &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_ICMPv4{ICMPv4: &flow.ICMPv4{Type: 8}}}},
+			peer: world,
+		},
+		{
+			name: "icmpv6",
+			flow: &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_ICMPv6{ICMPv6: &flow.ICMPv6{Type: 128}}}},
+			peer: world,
+		},
+		{
+			name: "pod without stable labels",
+			flow: tcpFlow(8080),
+			peer: peer{namespace: "shop", labels: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := kubernetesRule(tt.flow, tt.peer)
+			if ok != tt.wantOK {
+				t.Fatalf("kubernetesRule() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubernetesRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCiliumRule(t *testing.T) {
+	backendEndpoints := []suggest.EndpointSelector{{MatchLabels: map[string]string{namespaceLabel: "shop", "app": "backend"}}}
+
+	tests := []struct {
+		name   string
+		flow   *flow.Flow
+		peer   peer
+		want   suggest.EgressRule
+		wantOK bool
+	}{
+		{
+			name: "tcp to pod",
+			flow: tcpFlow(8080),
+			peer: backend,
+			want: suggest.EgressRule{
+				ToEndpoints: backendEndpoints,
+				ToPorts:     []suggest.PortRule{{Ports: []suggest.PortProtocol{{Port: "8080", Protocol: "TCP"}}}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "udp to fqdn",
+			flow: &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_UDP{UDP: &flow.UDP{DestinationPort: 123}}}},
+			peer: peer{fqdn: "time.example.com", ip: "203.0.113.10"},
+			want: suggest.EgressRule{
+				ToFQDNs: []suggest.FQDNSelector{{MatchName: "time.example.com"}},
+				ToPorts: []suggest.PortRule{{Ports: []suggest.PortProtocol{{Port: "123", Protocol: "UDP"}}}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "icmp to entity",
+			flow: &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_ICMPv4{ICMPv4: &flow.ICMPv4{Type: 8}}}},
+			peer: peer{entity: "kube-apiserver", ip: "10.0.0.1"},
+			want: suggest.EgressRule{
+				ToEntities: []string{"kube-apiserver"},
+				ICMPs:      []suggest.ICMPRule{{Fields: []suggest.ICMPField{{Family: "IPv4", Type: 8}}}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "http to ip",
+			flow: &flow.Flow{
+				L4: &flow.Layer4{Protocol: &flow.Layer4_TCP{TCP: &flow.TCP{DestinationPort: 80}}},
+				L7: &flow.Layer7{Record: &flow.Layer7_Http{Http: &flow.HTTP{Method: "GET", Url: "http://api/orders/42"}}},
+			},
+			peer: world,
+			want: suggest.EgressRule{
+				ToCIDR: []string{"203.0.113.10/32"},
+				ToPorts: []suggest.PortRule{{
+					Ports: []suggest.PortProtocol{{Port: "80", Protocol: "TCP"}},
+					Rules: &suggest.L7Rules{HTTP: []suggest.HTTPRule{{Method: "GET", Path: "/orders/[^/]+"}}},
+				}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "pod without stable labels",
+			flow: tcpFlow(8080),
+			peer: peer{namespace: "shop", labels: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ciliumRule(tt.flow, tt.peer)
+			if ok != tt.wantOK {
+				t.Fatalf("ciliumRule() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ciliumRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func tcpFlow(port uint32) *flow.Flow {
+	return &flow.Flow{L4: &flow.Layer4{Protocol: &flow.Layer4_TCP{TCP: &flow.TCP{DestinationPort: port}}}}
+}
+
+func ports(proto corev1.Protocol, port uint32) []networkingv1.NetworkPolicyPort {
+	return []networkingv1.NetworkPolicyPort{networkPolicyPort(proto, port)}
+}
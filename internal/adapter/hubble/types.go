@@ -1,17 +1,29 @@
 package hubble
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/instance"
 	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/suggest"
 	"github.com/cilium/cilium/api/v1/flow"
 	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const reportSource = "Blocked Egress"
+const (
+	reportSource  = "Blocked Egress"
+	defaultPolicy = "Egress Network Policy"
+)
 
 var consideredLabels = map[string]bool{
 	"jenkins/label":                 true,
@@ -20,19 +32,101 @@ var consideredLabels = map[string]bool{
 	"product.fenaco.com/name":       true,
 }
 
-func toItem(f *flow.Flow) *report.Item {
-	dest, protocol := destination(f)
-	if dest == "" {
+// rcodes are the names of the common DNS response codes
+var rcodes = map[uint32]string{
+	0: "NOERROR",
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+// converter converts the dropped flows into report items
+type converter struct {
+	defaultPolicy string
+	// infrastructure defines how drops that are no policy violations are handled
+	infrastructure string
+	// names are the names of the DNS answers, nil if disabled
+	names *fqdnCache
+	// aggregate collapses world addresses and ephemeral ports, nil if disabled
+	aggregate *aggregator
+	// services finds the services of the destination pods, nil if disabled
+	services *serviceResolver
+	// suggest is the kind of the suggested egress rules, empty if disabled
+	suggest string
+	// cluster is the name of the local cluster, the cluster of the node of each flow if empty
+	cluster string
+	// remotes are the remote clusters whose flows are written to their own reports
+	remotes map[string]bool
+	// ignoredClusters are the clusters whose flows were ignored by instance, they are logged once
+	ignoredClusters *sync.Map
+	// nodeClusters are the local clusters taken from the node names by instance, they are logged once
+	nodeClusters *sync.Map
+	// instance is the relay the flows are received from
+	instance instance.Instance
+	// policies looks up the labels of the denying policies, nil if disabled
+	policies *policyResolver
+}
+
+// denial is the part of a result that depends on the kind of the dropped flow
+type denial struct {
+	rule       string
+	protocol   string
+	message    string
+	properties map[string]string
+}
+
+func (c *converter) toItem(ctx context.Context, f *flow.Flow) *report.Item {
+	class := classify(f)
+	if !class.policy && c.infrastructure == infrastructureIgnore {
+		return nil
+	}
+	remote := c.remoteCluster(f)
+	if remote != "" && !c.remotes[remote] {
+		if _, logged := c.ignoredClusters.LoadOrStore(c.instance.Name+"/"+remote, true); !logged {
+			slog.InfoContext(ctx, "ignoring the flows of a cluster without kubeconfig",
+				"cluster", remote, "local-cluster", c.localCluster(f), "instance", c.instance.Name)
+		}
+		return nil
+	}
+	if c.cluster == "" {
+		if local := c.localCluster(f); local != "" {
+			if _, logged := c.nodeClusters.LoadOrStore(c.instance.Name+"/"+local, true); !logged {
+				slog.InfoContext(ctx, "using the cluster of the nodes as local cluster, as the cluster name is not configured",
+					"cluster", local, "variable", env.HubbleClusterName, "instance", c.instance.Name)
+			}
+		}
+	}
+	p := peerOf(f)
+	namedIP, dnsName := c.nameDestination(f)
+	if dnsName {
+		p.fqdn = f.DestinationNames[0]
+	}
+	pod, servicePort := c.serviceDestination(f)
+
+	var d *denial
+	switch l7 := f.GetL7(); {
+	case l7.GetDns() != nil:
+		d = dnsDenial(f, l7.GetDns())
+	case l7.GetHttp() != nil:
+		d = httpDenial(f, l7.GetHttp())
+	case l7.GetKafka() != nil:
+		d = kafkaDenial(f, l7.GetKafka())
+	default:
+		d = c.l4Denial(f, servicePort)
+	}
+	if d == nil {
 		return nil
 	}
 
 	pr := prv1alpha2.PolicyReportResult{
-		Category: f.TrafficDirection.String(),
-		Message:  f.DropReasonDesc.String(),
+		Category: class.category,
+		Message:  d.message,
 
-		Severity: "high",
-		Policy:   "Egress Network Policy",
-		Rule:     dest,
+		Severity: class.severity,
+		Policy:   c.defaultPolicy,
+		Rule:     d.rule,
 		// PolicyResult has one of the following values:
 		//   - pass: indicates that the policy requirements are met
 		//   - fail: indicates that the policy requirements are not met
@@ -40,7 +134,7 @@ func toItem(f *flow.Flow) *report.Item {
 		//   - error: indicates that the policy could not be evaluated
 		//   - skip: indicates that the policy was not selected based on user inputs or applicability
 		Result: "fail",
-		Scored: true,
+		Scored: report.Scored("fail"),
 		Source: reportSource,
 		Timestamp: metav1.Timestamp{
 			Nanos: f.Time.GetNanos(),
@@ -48,13 +142,282 @@ func toItem(f *flow.Flow) *report.Item {
 		Properties: map[string]string{
 			report.PropertyCreated: updatedTimeRFC3339(f),
 			report.PropertyUpdated: updatedTimeRFC3339(f),
-			"protocol":             protocol,
+			"protocol":             d.protocol,
+			"direction":            f.GetTrafficDirection().String(),
 		},
 	}
+	if f.GetDropReasonDesc() != flow.DropReason_DROP_REASON_UNKNOWN {
+		pr.Properties["drop-reason"] = f.GetDropReasonDesc().String()
+	}
+	maps.Copy(pr.Properties, d.properties)
+	c.instance.AddProperty(pr.Properties)
+	if namedIP != "" {
+		pr.Properties["destination-ip"] = namedIP
+	}
+	if pod != "" {
+		pr.Properties["destination-pod"] = pod
+	}
+	if _, targetPort, ok := l4Port(f); ok && servicePort != 0 {
+		pr.Properties["target-port"] = strconv.FormatUint(uint64(targetPort), 10)
+	}
+	if cluster := f.GetSource().GetClusterName(); cluster != "" {
+		pr.Properties["source-cluster"] = cluster
+	}
+	if cluster := f.GetDestination().GetClusterName(); cluster != "" {
+		pr.Properties["destination-cluster"] = cluster
+	}
+	if c.suggest != "" && class.policy {
+		if rule, err := c.suggestedRule(f, p); err != nil {
+			slog.ErrorContext(ctx, "failed to suggest an egress rule", "error", err)
+		} else if rule != "" {
+			pr.Properties[suggest.PropertyPolicy] = rule
+			pr.Properties[suggest.PropertyPolicyKind] = c.suggest
+		}
+	}
+
+	if !class.policy {
+		// infrastructure drops are not caused by a policy of the pod, they are reported on the namespace
+		pr.Source = infrastructureSource
+		pr.Policy = class.category
+		pr.Properties["pod"] = f.Source.PodName
+		addPodLabels(f, pr)
+		return report.NamespaceItemFor("cilium-infrastructure-drops", f.Source.Namespace, pr, f).
+			ForInstance(c.instance.Name).InCluster(remote).OnNode(nodeName(f))
+	}
 
+	c.addDeniedBy(ctx, f, &pr)
 	addPodLabels(f, pr)
 
-	return report.ItemFor("clilum-blocked-egress", f.Source.Namespace, f.Source.PodName, pr, f)
+	return report.ItemFor("cilium-blocked-egress", f.Source.Namespace, f.Source.PodName, pr, f).
+		ForInstance(c.instance.Name).InCluster(remote).OnNode(nodeName(f))
+}
+
+// nodeName returns the name of the node of the flow, the relay prefixes it with the cluster name
+func nodeName(f *flow.Flow) string {
+	_, node, ok := strings.Cut(f.GetNodeName(), "/")
+	if !ok {
+		return f.GetNodeName()
+	}
+	return node
+}
+
+// remoteCluster returns the cluster of the source pod if it is not the local cluster. In a ClusterMesh
+// the relay returns the flows of all clusters.
+func (c *converter) remoteCluster(f *flow.Flow) string {
+	local := c.localCluster(f)
+	if local == "" {
+		return ""
+	}
+	if cluster := f.GetSource().GetClusterName(); cluster != "" && cluster != local {
+		return cluster
+	}
+	return ""
+}
+
+// localCluster returns the configured cluster name, or the cluster of the node the flow was observed on,
+// as only the nodes of the local cluster are observed. Cilium prefixes the node names with the cluster name.
+func (c *converter) localCluster(f *flow.Flow) string {
+	if c.cluster != "" {
+		return c.cluster
+	}
+	cluster, _, ok := strings.Cut(f.GetNodeName(), "/")
+	if !ok {
+		return ""
+	}
+	return cluster
+}
+
+func (c *converter) l4Denial(f *flow.Flow, servicePort uint32) *denial {
+	dest, protocol := c.destination(f, servicePort)
+	if dest == "" {
+		return nil
+	}
+	return &denial{
+		rule:     dest,
+		protocol: protocol,
+		message:  f.DropReasonDesc.String(),
+	}
+}
+
+// dnsDenial reports a query denied by the DNS proxy because no toFQDNs rule matches
+func dnsDenial(f *flow.Flow, dns *flow.DNS) *denial {
+	fqdn := strings.TrimSuffix(dns.GetQuery(), ".")
+	if fqdn == "" {
+		return nil
+	}
+	d := &denial{
+		rule:     fqdn,
+		protocol: "DNS",
+		properties: map[string]string{
+			"query-type": strings.Join(dns.GetQtypes(), ","),
+		},
+	}
+	// the proxy answers with the configured rcode (REFUSED by default) or drops the query
+	outcome := "was denied"
+	switch {
+	case dns.GetRcode() != 0:
+		d.properties["rcode"] = rcodeName(dns.GetRcode())
+		outcome = "was answered with " + d.properties["rcode"]
+	case f.GetDropReasonDesc() != flow.DropReason_DROP_REASON_UNKNOWN:
+		outcome = "was dropped: " + f.GetDropReasonDesc().String()
+	}
+	d.message = fmt.Sprintf("%s/%s tried to resolve %s and %s", f.GetSource().GetNamespace(), f.GetSource().GetPodName(), fqdn, outcome)
+	return d
+}
+
+// httpDenial reports a request denied by the HTTP rules of a policy. The rule uses a path template,
+// so requests to the same resource with different ids are merged.
+func httpDenial(f *flow.Flow, http *flow.HTTP) *denial {
+	u, err := url.Parse(http.GetUrl())
+	if err != nil || http.GetMethod() == "" {
+		return nil
+	}
+	host := u.Host
+	if host == "" {
+		host = destinationHost(f)
+	}
+	rule := fmt.Sprintf("%s %s%s", http.GetMethod(), host, report.PathTemplate(u.Path))
+	d := &denial{
+		rule:     rule,
+		protocol: "HTTP",
+		message:  fmt.Sprintf("%s/%s request %s %s was denied", f.Source.Namespace, f.Source.PodName, http.GetMethod(), u.Redacted()),
+		properties: map[string]string{
+			"method": http.GetMethod(),
+			"host":   host,
+			"path":   u.Path,
+		},
+	}
+	if http.GetCode() != 0 {
+		d.properties["code"] = strconv.FormatUint(uint64(http.GetCode()), 10)
+	}
+	return d
+}
+
+// kafkaDenial reports a Kafka request denied by the Kafka rules of a policy
+func kafkaDenial(f *flow.Flow, kafka *flow.Kafka) *denial {
+	if kafka.GetApiKey() == "" {
+		return nil
+	}
+	d := &denial{
+		rule:     fmt.Sprintf("%s %s %s", destinationHost(f), kafka.GetApiKey(), kafka.GetTopic()),
+		protocol: "Kafka",
+		message: fmt.Sprintf("%s/%s kafka %s of topic %q was denied", f.Source.Namespace, f.Source.PodName,
+			kafka.GetApiKey(), kafka.GetTopic()),
+		properties: map[string]string{
+			"api-key": kafka.GetApiKey(),
+			"topic":   kafka.GetTopic(),
+		},
+	}
+	if kafka.GetErrorCode() != 0 {
+		d.properties["error-code"] = strconv.Itoa(int(kafka.GetErrorCode()))
+	}
+	return d
+}
+
+// nameDestination names a destination that is neither named nor a pod by the DNS answers of the source pod
+// or by the aggregation of the address. The address is returned if the destination was named, and true if the name
+// is resolved by DNS.
+func (c *converter) nameDestination(f *flow.Flow) (string, bool) {
+	ip := f.GetIP().GetDestination()
+	if len(f.GetDestinationNames()) > 0 || f.GetDestination().GetNamespace() != "" || ip == "" {
+		return "", false
+	}
+	if c.names != nil {
+		if name, ok := c.names.lookup(f.GetSource().GetNamespace(), f.GetSource().GetPodName(), ip); ok {
+			f.DestinationNames = []string{name}
+			return ip, true
+		}
+	}
+	if c.aggregate != nil {
+		if name := c.aggregate.name(ip, f.GetDestination().GetLabels()); name != "" {
+			f.DestinationNames = []string{name}
+			return ip, false
+		}
+	}
+	return "", false
+}
+
+// serviceDestination names a destination pod by its service, as the names of the pods change with every rollout.
+// The pod and the service port of the target port are returned if the destination was named.
+func (c *converter) serviceDestination(f *flow.Flow) (string, uint32) {
+	dest := f.GetDestination()
+	if c.services == nil || len(f.GetDestinationNames()) > 0 || dest.GetNamespace() == "" || dest.GetPodName() == "" {
+		return "", 0
+	}
+	// the endpoint slices are of the local cluster only
+	if cluster, local := dest.GetClusterName(), c.localCluster(f); local != "" && cluster != "" && cluster != local {
+		return "", 0
+	}
+	// the flows are translated to the pod, the service is matched by its target port
+	protocol, targetPort, _ := l4Port(f)
+	service, servicePort := c.services.service(f.GetIP().GetDestination(), protocol, targetPort)
+	if service == "" {
+		return "", 0
+	}
+	f.DestinationNames = []string{service}
+	return dest.GetNamespace() + "/" + dest.GetPodName(), servicePort
+}
+
+// destinationHost returns the name, pod or IP of the destination of a flow
+func destinationHost(f *flow.Flow) string {
+	switch {
+	case len(f.DestinationNames) > 0:
+		return f.DestinationNames[0]
+	case f.Destination != nil && f.Destination.Namespace != "":
+		return f.Destination.Namespace + "/" + f.Destination.PodName
+	case f.IP != nil:
+		return f.IP.Destination
+	}
+	return ""
+}
+
+func rcodeName(rcode uint32) string {
+	if name, ok := rcodes[rcode]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(rcode), 10)
+}
+
+// addDeniedBy sets the policy that denied the flow as <namespace>/<name>, if known
+func (c *converter) addDeniedBy(ctx context.Context, f *flow.Flow, pr *prv1alpha2.PolicyReportResult) {
+	policies := f.GetEgressDeniedBy()
+	if f.GetTrafficDirection() == flow.TrafficDirection_INGRESS {
+		policies = f.GetIngressDeniedBy()
+	}
+
+	var denying []*flow.Policy
+	var names []string
+	for _, p := range policies {
+		if p.GetName() != "" {
+			denying = append(denying, p)
+			names = append(names, policyName(p))
+		}
+	}
+	if len(denying) == 0 {
+		return
+	}
+	if len(denying) > 1 {
+		pr.Properties["denied-by"] = strings.Join(names, ",")
+	}
+
+	p := denying[0]
+	pr.Policy = names[0]
+	if p.GetKind() != "" {
+		pr.Properties["policy-kind"] = p.GetKind()
+	}
+	// the policies of remote clusters can not be looked up
+	if c.policies != nil && c.remoteCluster(f) == "" {
+		for k, v := range c.policies.labels(ctx, p) {
+			pr.Properties[propertyPolicyLabelPrefix+k] = v
+		}
+	}
+}
+
+func policyName(p *flow.Policy) string {
+	if p.GetNamespace() == "" {
+		return p.GetName()
+	}
+	return p.GetNamespace() + "/" + p.GetName()
 }
 
 func addPodLabels(f *flow.Flow, pr prv1alpha2.PolicyReportResult) {
@@ -67,21 +430,27 @@ func addPodLabels(f *flow.Flow, pr prv1alpha2.PolicyReportResult) {
 	}
 }
 
-func destination(f *flow.Flow) (string, string) {
-	if f.L4 != nil {
-		if f.L4.GetTCP() != nil {
-			if len(f.DestinationNames) == 0 {
-				if f.Destination != nil && f.Destination.Namespace != "" {
-					return fmt.Sprintf("%s/%s:%d", f.Destination.Namespace, f.Destination.PodName, f.L4.GetTCP().DestinationPort), "TCP"
-				} else if f.IP != nil {
-					return fmt.Sprintf("%s:%d", f.IP.Destination, f.L4.GetTCP().DestinationPort), "TCP"
-				}
-			} else {
-				return fmt.Sprintf("%s:%d", f.DestinationNames[0], f.L4.GetTCP().DestinationPort), "TCP"
+// destination returns the destination and protocol of a flow, the service port replaces the port of the flow if set
+func (c *converter) destination(f *flow.Flow, servicePort uint32) (string, string) {
+	if protocol, flowPort, ok := l4Port(f); ok {
+		port := c.aggregate.port(flowPort)
+		if servicePort != 0 {
+			port = strconv.FormatUint(uint64(servicePort), 10)
+		}
+		if len(f.DestinationNames) == 0 {
+			if f.Destination != nil && f.Destination.Namespace != "" {
+				return fmt.Sprintf("%s/%s:%s", f.Destination.Namespace, f.Destination.PodName, port), string(protocol)
+			} else if f.IP != nil {
+				return fmt.Sprintf("%s:%s", f.IP.Destination, port), string(protocol)
 			}
-		} else if f.L4.GetICMPv4() != nil {
-			return f.IP.Destination, "ping"
+		} else {
+			return fmt.Sprintf("%s:%s", f.DestinationNames[0], port), string(protocol)
+		}
+	} else if f.GetL4().GetICMPv4() != nil {
+		if len(f.DestinationNames) > 0 {
+			return f.DestinationNames[0], "ping"
 		}
+		return f.GetIP().GetDestination(), "ping"
 	}
 	return "", ""
 }
@@ -0,0 +1,184 @@
+package hubble
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/suggest"
+	"github.com/cilium/cilium/api/v1/flow"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestDNSDenial(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		rcode       uint32
+		dropReason  flow.DropReason
+		wantRule    string
+		wantMessage string
+	}{
+		{
+			name:        "refused",
+			query:       "api.example.com.",
+			rcode:       5,
+			wantRule:    "api.example.com",
+			wantMessage: "shop/orders-1 tried to resolve api.example.com and was answered with REFUSED",
+		},
+		{
+			name:        "name error",
+			query:       "api.example.com.",
+			rcode:       3,
+			wantRule:    "api.example.com",
+			wantMessage: "shop/orders-1 tried to resolve api.example.com and was answered with NXDOMAIN",
+		},
+		{
+			name:        "unknown rcode",
+			query:       "api.example.com",
+			rcode:       23,
+			wantRule:    "api.example.com",
+			wantMessage: "shop/orders-1 tried to resolve api.example.com and was answered with 23",
+		},
+		{
+			name:        "dropped",
+			query:       "api.example.com.",
+			dropReason:  flow.DropReason_POLICY_DENIED,
+			wantRule:    "api.example.com",
+			wantMessage: "shop/orders-1 tried to resolve api.example.com and was dropped: POLICY_DENIED",
+		},
+		{
+			name:        "no rcode and no drop reason",
+			query:       "api.example.com.",
+			wantRule:    "api.example.com",
+			wantMessage: "shop/orders-1 tried to resolve api.example.com and was denied",
+		},
+		{
+			name: "no query",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flow.Flow{
+				Source:         &flow.Endpoint{Namespace: "shop", PodName: "orders-1"},
+				DropReasonDesc: tt.dropReason,
+			}
+			d := dnsDenial(f, &flow.DNS{Query: tt.query, Rcode: tt.rcode, Qtypes: []string{"A"}})
+			if tt.wantRule == "" {
+				if d != nil {
+					t.Errorf("dnsDenial() = %+v, want nil", d)
+				}
+				return
+			}
+			if d == nil {
+				t.Fatal("dnsDenial() = nil")
+			}
+			if d.rule != tt.wantRule || d.message != tt.wantMessage {
+				t.Errorf("dnsDenial() = (%q, %q), want (%q, %q)", d.rule, d.message, tt.wantRule, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRemoteCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		local   string
+		node    string
+		cluster string
+		want    string
+	}{
+		{name: "no cluster name configured", cluster: "cluster-a"},
+		{name: "cluster of the node", node: "cluster-a/node-1", cluster: "cluster-b", want: "cluster-b"},
+		{name: "local cluster of the node", node: "cluster-a/node-1", cluster: "cluster-a"},
+		{name: "configured cluster name", local: "cluster-a", node: "cluster-b/node-1", cluster: "cluster-b", want: "cluster-b"},
+		{name: "local cluster", local: "cluster-a", cluster: "cluster-a"},
+		{name: "remote cluster", local: "cluster-a", cluster: "cluster-b", want: "cluster-b"},
+		{name: "flow without cluster", local: "cluster-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{cluster: tt.local}
+			f := &flow.Flow{NodeName: tt.node, Source: &flow.Endpoint{ClusterName: tt.cluster}}
+			if got := c.remoteCluster(f); got != tt.want {
+				t.Errorf("remoteCluster() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToItemL4(t *testing.T) {
+	udp := &flow.Layer4{Protocol: &flow.Layer4_UDP{UDP: &flow.UDP{SourcePort: 40000, DestinationPort: 123}}}
+	sctp := &flow.Layer4{Protocol: &flow.Layer4_SCTP{SCTP: &flow.SCTP{SourcePort: 40000, DestinationPort: 3868}}}
+	backendPod := &flow.Endpoint{Namespace: "shop", PodName: "backend-1", Labels: []string{"k8s:app=backend"}}
+
+	tests := []struct {
+		name         string
+		l4           *flow.Layer4
+		destination  *flow.Endpoint
+		suggest      string
+		wantRule     string
+		wantProtocol string
+		wantPolicy   any
+	}{
+		{
+			name:         "udp to world",
+			l4:           udp,
+			suggest:      suggest.KindKubernetes,
+			wantRule:     "203.0.113.10:123",
+			wantProtocol: "UDP",
+			wantPolicy: networkingv1.NetworkPolicyEgressRule{
+				To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "203.0.113.10/32"}}},
+				Ports: ports(corev1.ProtocolUDP, 123),
+			},
+		},
+		{
+			name:         "sctp to pod",
+			l4:           sctp,
+			destination:  backendPod,
+			suggest:      suggest.KindCilium,
+			wantRule:     "shop/backend-1:3868",
+			wantProtocol: "SCTP",
+			wantPolicy: suggest.EgressRule{
+				ToEndpoints: []suggest.EndpointSelector{{MatchLabels: map[string]string{namespaceLabel: "shop", "app": "backend"}}},
+				ToPorts:     []suggest.PortRule{{Ports: []suggest.PortProtocol{{Port: "3868", Protocol: "SCTP"}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flow.Flow{
+				Source:           &flow.Endpoint{Namespace: "shop", PodName: "frontend-1"},
+				Destination:      tt.destination,
+				IP:               &flow.IP{Source: "10.0.0.1", Destination: "203.0.113.10"},
+				L4:               tt.l4,
+				Verdict:          flow.Verdict_DROPPED,
+				DropReasonDesc:   flow.DropReason_POLICY_DENIED,
+				TrafficDirection: flow.TrafficDirection_EGRESS,
+			}
+			if ignoreFlow(f) {
+				t.Fatal("ignoreFlow() = true, want false")
+			}
+
+			c := &converter{
+				defaultPolicy: defaultPolicy, suggest: tt.suggest, ignoredClusters: &sync.Map{}, nodeClusters: &sync.Map{},
+			}
+			item := c.toItem(t.Context(), f)
+			if item == nil {
+				t.Fatal("toItem() = nil")
+			}
+			r := item.Result()
+			if r.Rule != tt.wantRule || r.Properties["protocol"] != tt.wantProtocol {
+				t.Errorf("rule, protocol = %q, %q, want %q, %q", r.Rule, r.Properties["protocol"], tt.wantRule, tt.wantProtocol)
+			}
+			want, err := suggest.Rule(tt.wantPolicy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Properties[suggest.PropertyPolicy] != want || r.Properties[suggest.PropertyPolicyKind] != tt.suggest {
+				t.Errorf("suggested %s policy = %q, want %s policy %q", r.Properties[suggest.PropertyPolicyKind],
+					r.Properties[suggest.PropertyPolicy], tt.suggest, want)
+			}
+		})
+	}
+}
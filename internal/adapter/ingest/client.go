@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/metrics"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/webhook"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	statusReported = "reported"
+	statusSkipped  = "skipped"
+	statusInvalid  = "invalid"
+)
+
+var (
+	eventsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "ingest_events",
+			Namespace: metrics.Namespace,
+			Help:      "The number of events received by the ingest adapter by mapping and status",
+		},
+		[]string{"mapping", "status"},
+	)
+	// the adapter is started again for every leadership term
+	registerMetrics = sync.OnceFunc(func() { prometheus.MustRegister(eventsCounter) })
+)
+
+// Run receives JSON events (a single event or NDJSON) on POST /<mapping> and maps them with the configured expressions
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	address, ok := os.LookupEnv(env.IngestWebhookAddress)
+	if !ok {
+		return fmt.Errorf("ingest webhook address variable must %q be set", env.IngestWebhookAddress)
+	}
+	if env.Empty(env.IngestConfig) {
+		return fmt.Errorf("ingest config variable must %q be set", env.IngestConfig)
+	}
+	mappings, err := readConfig(os.Getenv(env.IngestConfig))
+	if err != nil {
+		return err
+	}
+	t := webhook.TLS{
+		CertFile:     os.Getenv(env.IngestWebhookTLSCert),
+		KeyFile:      os.Getenv(env.IngestWebhookTLSKey),
+		ClientCAFile: os.Getenv(env.IngestWebhookTLSClientCA),
+	}
+	token := os.Getenv(env.IngestBearerToken)
+	if token == "" && t.ClientCAFile == "" {
+		slog.WarnContext(ctx, "ingest webhook accepts unauthenticated requests",
+			"token", env.IngestBearerToken, "client-ca", env.IngestWebhookTLSClientCA)
+	}
+	registerMetrics()
+
+	mux := http.NewServeMux()
+	for _, m := range mappings {
+		mux.HandleFunc("POST /"+m.name, func(w http.ResponseWriter, r *http.Request) {
+			m.handle(ctx, w, r, reportChan)
+		})
+	}
+
+	return webhook.ServeTLS(ctx, "Ingest", address, t, webhook.Authorize(token, mux))
+}
+
+func (m *mapping) handle(ctx context.Context, w http.ResponseWriter, r *http.Request, reportChan chan *report.Item) {
+	received := time.Now()
+	counts := map[string]int{}
+	dec := newDecoder(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize))
+	for {
+		var event any
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			http.Error(w, fmt.Sprintf("error unmarshalling event: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		item, err := m.toItem(event, received)
+		st := statusReported
+		switch {
+		case err != nil:
+			slog.WarnContext(ctx, "could not map event", "mapping", m.name, "error", err)
+			st = statusInvalid
+		case item == nil:
+			st = statusSkipped
+		default:
+			select {
+			case reportChan <- item:
+			case <-ctx.Done():
+				http.Error(w, "shutting down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		counts[st]++
+		eventsCounter.WithLabelValues(m.name, st).Inc()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(counts)
+}
+
+// newDecoder keeps the numbers of the events as sent (e.g. 1234567 instead of 1.234567e+06)
+func newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec
+}
@@ -0,0 +1,260 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	languageJSONPath = "jsonpath"
+	languageCEL      = "cel"
+
+	// celVariable is the name of the variable the event is bound to in CEL expressions
+	celVariable = "event"
+)
+
+// Config is the mapping configuration of the ingest adapter
+type Config struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping maps the JSON events posted to /<name> into report items.
+// Each field is a JSONPath template (e.g. `{.metadata.namespace}` or a constant value) or a CEL expression on `event`.
+type Mapping struct {
+	Name string `json:"name"`
+	// Language of the expressions, jsonpath (default) or cel
+	Language   string            `json:"language,omitempty"`
+	Fields     Fields            `json:"fields"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// Fields are the expressions of the PolicyReportResult fields. Events without namespace are skipped,
+// events without pod are reported on the namespace.
+type Fields struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod,omitempty"`
+	Policy    string `json:"policy"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Result    string `json:"result,omitempty"`
+	Source    string `json:"source,omitempty"`
+	// Timestamp of the event in RFC3339, the receive time is used if not set
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// readConfig reads and compiles the mappings of the config file
+func readConfig(path string) ([]*mapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		return nil, fmt.Errorf("invalid ingest config %q: %w", path, err)
+	}
+	if len(cfg.Mappings) == 0 {
+		return nil, fmt.Errorf("ingest config %q has no mappings", path)
+	}
+
+	names := map[string]bool{}
+	var mappings []*mapping
+	for _, m := range cfg.Mappings {
+		if errs := validation.IsDNS1123Label(m.Name); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid mapping name %q: %s", m.Name, strings.Join(errs, ", "))
+		}
+		if names[m.Name] {
+			return nil, fmt.Errorf("duplicate mapping name %q", m.Name)
+		}
+		names[m.Name] = true
+
+		cm, err := m.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid mapping %q: %w", m.Name, err)
+		}
+		mappings = append(mappings, cm)
+	}
+	return mappings, nil
+}
+
+// mapping is a compiled Mapping
+type mapping struct {
+	name       string
+	namespace  expression
+	pod        expression
+	policy     expression
+	rule       expression
+	message    expression
+	category   expression
+	severity   expression
+	result     expression
+	source     expression
+	timestamp  expression
+	properties map[string]expression
+	// convert prepares the decoded event for the expressions, nil if the event is used as decoded
+	convert func(event any) any
+}
+
+func (m Mapping) compile() (*mapping, error) {
+	var compile func(expr string) (expression, error)
+	var convert func(event any) any
+	switch m.Language {
+	case "", languageJSONPath:
+		compile = compileJSONPath
+	case languageCEL:
+		env, err := cel.NewEnv(cel.Variable(celVariable, cel.DynType))
+		if err != nil {
+			return nil, err
+		}
+		compile = func(expr string) (expression, error) {
+			return compileCEL(env, expr)
+		}
+		convert = celValue
+	default:
+		return nil, fmt.Errorf("unknown language %q, must be one of %q, %q", m.Language, languageJSONPath, languageCEL)
+	}
+
+	if m.Fields.Namespace == "" || m.Fields.Policy == "" || m.Fields.Rule == "" {
+		return nil, errors.New("the namespace, policy and rule fields are required")
+	}
+
+	cm := &mapping{name: m.Name, properties: map[string]expression{}, convert: convert}
+	fields := []struct {
+		target *expression
+		expr   string
+	}{
+		{&cm.namespace, m.Fields.Namespace},
+		{&cm.pod, m.Fields.Pod},
+		{&cm.policy, m.Fields.Policy},
+		{&cm.rule, m.Fields.Rule},
+		{&cm.message, m.Fields.Message},
+		{&cm.category, m.Fields.Category},
+		{&cm.severity, m.Fields.Severity},
+		{&cm.result, m.Fields.Result},
+		{&cm.source, m.Fields.Source},
+		{&cm.timestamp, m.Fields.Timestamp},
+	}
+	for _, f := range fields {
+		if f.expr == "" {
+			*f.target = constant("")
+			continue
+		}
+		e, err := compile(f.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", f.expr, err)
+		}
+		*f.target = e
+	}
+	for name, expr := range m.Properties {
+		e, err := compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q of property %q: %w", expr, name, err)
+		}
+		cm.properties[name] = e
+	}
+	return cm, nil
+}
+
+// expression evaluates a field of an event into a string
+type expression interface {
+	eval(event any) (string, error)
+}
+
+type constant string
+
+func (c constant) eval(any) (string, error) {
+	return string(c), nil
+}
+
+// jsonPathExpression is a JSONPath template, the parsed template keeps state while executing and can not be shared
+type jsonPathExpression struct {
+	mux sync.Mutex
+	jp  *jsonpath.JSONPath
+}
+
+func compileJSONPath(expr string) (expression, error) {
+	jp := jsonpath.New("").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, err
+	}
+	return &jsonPathExpression{jp: jp}, nil
+}
+
+func (e *jsonPathExpression) eval(event any) (string, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var buf bytes.Buffer
+	if err := e.jp.Execute(&buf, event); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type celExpression struct {
+	prg cel.Program
+}
+
+func compileCEL(env *cel.Env, expr string) (expression, error) {
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &celExpression{prg: prg}, nil
+}
+
+func (e *celExpression) eval(event any) (string, error) {
+	out, _, err := e.prg.Eval(map[string]any{celVariable: event})
+	if err != nil {
+		return "", err
+	}
+	if out == types.NullValue {
+		return "", nil
+	}
+	if f, ok := out.Value().(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	return fmt.Sprint(out.Value()), nil
+}
+
+// celValue converts the json.Number values of an event into int64 or float64, so CEL can calculate with them
+func celValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = celValue(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = celValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
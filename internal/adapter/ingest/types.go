@@ -0,0 +1,107 @@
+package ingest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// toItem maps an event, it returns nil if the event has no namespace
+func (m *mapping) toItem(event any, received time.Time) (*report.Item, error) {
+	v := &values{event: event}
+	if m.convert != nil {
+		v.event = m.convert(event)
+	}
+	namespace := v.eval(m.namespace)
+	pod := v.eval(m.pod)
+	policy := v.eval(m.policy)
+	rule := v.eval(m.rule)
+	message := v.eval(m.message)
+	category := v.eval(m.category)
+	severity := v.eval(m.severity)
+	result := v.eval(m.result)
+	source := v.eval(m.source)
+	timestamp := v.eval(m.timestamp)
+	properties := map[string]string{}
+	for name, e := range m.properties {
+		if value := v.eval(e); value != "" {
+			properties[name] = value
+		}
+	}
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	if namespace == "" {
+		return nil, nil
+	}
+	if policy == "" || rule == "" {
+		return nil, fmt.Errorf("policy %q and rule %q must not be empty", policy, rule)
+	}
+
+	sev, err := report.ParseSeverity(severity, report.DefaultSeverity)
+	if err != nil {
+		return nil, err
+	}
+	res, err := report.ParseResult(result, report.DefaultResult)
+	if err != nil {
+		return nil, err
+	}
+	if source == "" {
+		source = m.name
+	}
+
+	ts := received
+	if timestamp != "" {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+		ts = t
+	}
+	properties[report.PropertyCreated] = ts.Format(time.RFC3339)
+	properties[report.PropertyUpdated] = ts.Format(time.RFC3339)
+	properties["mapping"] = m.name
+
+	pr := prv1alpha2.PolicyReportResult{
+		Category: category,
+		Message:  message,
+
+		Severity: sev,
+		Policy:   policy,
+		Rule:     rule,
+		Result:   res,
+		Scored:   report.Scored(res),
+		Source:   source,
+		Timestamp: metav1.Timestamp{
+			Seconds: ts.Unix(),
+			Nanos:   int32(ts.Nanosecond()), // #nosec G115 nanoseconds are within int32
+		},
+		Properties: properties,
+	}
+
+	if pod != "" {
+		return report.ItemFor("ingest", namespace, pod, pr, event), nil
+	}
+	return report.NamespaceItemFor("ingest", namespace, pr, event), nil
+}
+
+// values evaluates the expressions of an event and keeps the first error
+type values struct {
+	event any
+	err   error
+}
+
+func (v *values) eval(e expression) string {
+	if v.err != nil {
+		return ""
+	}
+	value, err := e.eval(v.event)
+	if err != nil {
+		v.err = err
+	}
+	return value
+}
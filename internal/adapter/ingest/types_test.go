@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+const trivyEvent = `{
+  "metadata": {"namespace": "shop", "labels": {"pod": "backend-7d9f"}},
+  "report": {"vulnerabilityID": "CVE-2024-1234", "severity": "HIGH", "resource": "openssl", "title": "openssl overflow"},
+  "count": 3,
+  "size": 1234567,
+  "score": 7.5,
+  "time": "2026-10-19T10:00:00Z"
+}`
+
+func TestMappingToItem(t *testing.T) {
+	received := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		mapping       Mapping
+		event         string
+		wantNil       bool
+		wantErr       bool
+		wantNamespace string
+		wantPod       string
+		want          prv1alpha2.PolicyReportResult
+		wantCreated   string
+	}{
+		{
+			name: "jsonpath",
+			mapping: Mapping{
+				Name: "trivy",
+				Fields: Fields{
+					Namespace: "{.metadata.namespace}",
+					Pod:       "{.metadata.labels.pod}",
+					Policy:    "{.report.vulnerabilityID}",
+					Rule:      "{.report.resource}",
+					Message:   "{.report.title}",
+					Category:  "vulnerability",
+					Timestamp: "{.time}",
+				},
+				Properties: map[string]string{
+					"count": "{.count}", "size": "{.size}", "score": "{.score}", "missing": "{.missing}",
+				},
+			},
+			event:         trivyEvent,
+			wantNamespace: "shop",
+			wantPod:       "backend-7d9f",
+			want: prv1alpha2.PolicyReportResult{
+				Policy: "CVE-2024-1234", Rule: "openssl", Message: "openssl overflow", Category: "vulnerability",
+				Severity: "medium", Result: "fail", Scored: true, Source: "trivy",
+				Properties: map[string]string{"count": "3", "size": "1234567", "score": "7.5", "mapping": "trivy"},
+			},
+			wantCreated: "2026-10-19T10:00:00Z",
+		},
+		{
+			name: "cel",
+			mapping: Mapping{
+				Name:     "trivy",
+				Language: languageCEL,
+				Fields: Fields{
+					Namespace: "event.metadata.namespace",
+					Policy:    "event.report.vulnerabilityID",
+					Rule:      "event.report.resource",
+					Severity:  `event.report.severity == "HIGH" ? "high" : "low"`,
+					Result:    `event.count > 5 ? "fail" : "warn"`,
+					Source:    `"Trivy"`,
+				},
+				Properties: map[string]string{
+					"size": "event.size", "next": "event.size + 1", "score": "event.score", "scaled": "event.score * 1e6",
+				},
+			},
+			event:         trivyEvent,
+			wantNamespace: "shop",
+			want: prv1alpha2.PolicyReportResult{
+				Policy: "CVE-2024-1234", Rule: "openssl", Severity: "high", Result: "warn", Source: "Trivy",
+				Properties: map[string]string{
+					"size": "1234567", "next": "1234568", "score": "7.5", "scaled": "7500000", "mapping": "trivy",
+				},
+			},
+			wantCreated: "2026-10-19T12:00:00Z",
+		},
+		{
+			name: "event without namespace is skipped",
+			mapping: Mapping{
+				Name:   "trivy",
+				Fields: Fields{Namespace: "{.namespace}", Policy: "{.report.vulnerabilityID}", Rule: "{.report.resource}"},
+			},
+			event:   trivyEvent,
+			wantNil: true,
+		},
+		{
+			name: "empty policy",
+			mapping: Mapping{
+				Name:   "trivy",
+				Fields: Fields{Namespace: "{.metadata.namespace}", Policy: "{.policy}", Rule: "{.report.resource}"},
+			},
+			event:   trivyEvent,
+			wantErr: true,
+		},
+		{
+			name: "invalid severity",
+			mapping: Mapping{
+				Name: "trivy",
+				Fields: Fields{
+					Namespace: "{.metadata.namespace}", Policy: "{.report.vulnerabilityID}", Rule: "{.report.resource}",
+					Severity: "{.report.severity}",
+				},
+			},
+			event:   trivyEvent,
+			wantErr: true,
+		},
+		{
+			name: "invalid result",
+			mapping: Mapping{
+				Name: "trivy",
+				Fields: Fields{
+					Namespace: "{.metadata.namespace}", Policy: "{.report.vulnerabilityID}", Rule: "{.report.resource}",
+					Result: "denied",
+				},
+			},
+			event:   trivyEvent,
+			wantErr: true,
+		},
+		{
+			name: "invalid timestamp",
+			mapping: Mapping{
+				Name: "trivy",
+				Fields: Fields{
+					Namespace: "{.metadata.namespace}", Policy: "{.report.vulnerabilityID}", Rule: "{.report.resource}",
+					Timestamp: "{.count}",
+				},
+			},
+			event:   trivyEvent,
+			wantErr: true,
+		},
+		{
+			name: "cel evaluation error",
+			mapping: Mapping{
+				Name:     "trivy",
+				Language: languageCEL,
+				Fields:   Fields{Namespace: "event.metadata.namespace", Policy: "event.missing.id", Rule: `"rule"`},
+			},
+			event:   trivyEvent,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.mapping.compile()
+			if err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			var event any
+			if err := newDecoder(strings.NewReader(tt.event)).Decode(&event); err != nil {
+				t.Fatal(err)
+			}
+
+			item, err := m.toItem(event, received)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (item == nil) != tt.wantNil {
+				t.Fatalf("toItem() = %v, wantNil %v", item, tt.wantNil)
+			}
+			if tt.wantNil {
+				return
+			}
+
+			if item.Namespace != tt.wantNamespace || item.Name != tt.wantPod || item.NamespaceScoped() != (tt.wantPod == "") {
+				t.Errorf("toItem() target = %s (namespace scoped %v), want %s/%s", item.ObjectKey, item.NamespaceScoped(),
+					tt.wantNamespace, tt.wantPod)
+			}
+			got := item.Result()
+			if got.Policy != tt.want.Policy || got.Rule != tt.want.Rule || got.Message != tt.want.Message ||
+				got.Category != tt.want.Category || got.Severity != tt.want.Severity || got.Result != tt.want.Result ||
+				got.Scored != tt.want.Scored || got.Source != tt.want.Source {
+				t.Errorf("toItem() result = %+v, want %+v", got, tt.want)
+			}
+			for k, v := range tt.want.Properties {
+				if got.Properties[k] != v {
+					t.Errorf("property %q = %q, want %q", k, got.Properties[k], v)
+				}
+			}
+			if _, ok := got.Properties["missing"]; ok {
+				t.Errorf("empty property %q must not be set", "missing")
+			}
+			if got.Properties["created"] != tt.wantCreated {
+				t.Errorf("created = %q, want %q", got.Properties["created"], tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestMappingCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		wantErr bool
+	}{
+		{
+			name:    "jsonpath",
+			mapping: Mapping{Name: "a", Fields: Fields{Namespace: "{.ns}", Policy: "{.policy}", Rule: "{.rule}"}},
+		},
+		{
+			name:    "missing rule",
+			mapping: Mapping{Name: "a", Fields: Fields{Namespace: "{.ns}", Policy: "{.policy}"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid jsonpath",
+			mapping: Mapping{Name: "a", Fields: Fields{Namespace: "{.ns", Policy: "{.policy}", Rule: "{.rule}"}},
+			wantErr: true,
+		},
+		{
+			name: "invalid cel",
+			mapping: Mapping{Name: "a", Language: languageCEL,
+				Fields: Fields{Namespace: "event.ns +", Policy: "event.policy", Rule: "event.rule"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown language",
+			mapping: Mapping{Name: "a", Language: "rego", Fields: Fields{Namespace: "ns", Policy: "policy", Rule: "rule"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.mapping.compile(); (err != nil) != tt.wantErr {
+				t.Errorf("compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
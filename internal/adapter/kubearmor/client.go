@@ -4,25 +4,54 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/instance"
 	"github.com/bakito/policy-report-publisher/internal/report"
 	"github.com/kubearmor/kubearmor-client/k8s"
 	klog "github.com/kubearmor/kubearmor-client/log"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 )
 
+const defaultTLSCertPath = "/var/lib/kubearmor/tls"
+
 func Run(ctx context.Context, reportChan chan *report.Item) error {
+	instances, err := instance.Parse(env.KubeArmorServiceName)
+	if err != nil {
+		return err
+	}
+
+	// in node local mode the report handler skips the alerts of the pods on other nodes
+	return instance.Run(ctx, instances, func(ctx context.Context, i instance.Instance) error {
+		return runInstance(ctx, i, reportChan)
+	})
+}
+
+func runInstance(ctx context.Context, i instance.Instance, reportChan chan *report.Item) error {
 	eventChan := make(chan klog.EventInfo)
 	o := klog.Options{
 		EventChan: eventChan,
 		LogFilter: "all",
 	}
-	cl, err := newLogClient(o)
+	if env.Active(i.Var(env.KubeArmorTLS)) {
+		o.Secure = true
+		o.TlsCertPath = env.String(i.Var(env.KubeArmorTLSCertPath), defaultTLSCertPath)
+	}
+	cl, err := newLogClient(i, o)
 	if err != nil {
 		return err
 	}
 
+	r, err := newResults(i)
+	if err != nil {
+		return err
+	}
+
+	namespaces := map[string]bool{}
+	for _, ns := range env.List(i.Var(env.KubeArmorNamespaces), nil) {
+		namespaces[ns] = true
+	}
+
 	errChan := make(chan error, 1)
 	go func() {
 		if err := cl.WatchAlerts(o); err != nil {
@@ -43,20 +72,47 @@ func Run(ctx context.Context, reportChan chan *report.Item) error {
 			if err := json.Unmarshal(event.Data, a); err != nil {
 				return fmt.Errorf("error unmarshalling alert: %w", err)
 			}
+			if len(namespaces) > 0 && !namespaces[a.NamespaceName] {
+				continue
+			}
 
-			reportChan <- a.toItem()
+			select {
+			case reportChan <- a.toItem(i, r):
+			case <-ctx.Done():
+				close(eventChan)
+				return nil
+			}
 		}
 	}
 }
 
-func newLogClient(o klog.Options) (*klog.Feeder, error) {
-	if gRPC, ok := os.LookupEnv(env.KubeArmorServiceName); ok {
-		client, err := k8s.ConnectK8sClient()
-		if err != nil {
-			return nil, err
-		}
-		return klog.NewClient(gRPC, o, client.K8sClientset)
+func newResults(i instance.Instance) (results, error) {
+	allow, err := parseResult(i.Var(env.KubeArmorAllowResult), "pass")
+	if err != nil {
+		return results{}, err
+	}
+	posture, err := parseResult(i.Var(env.KubeArmorPostureResult), "")
+	if err != nil {
+		return results{}, err
+	}
+	return results{allow: allow, posture: posture}, nil
+}
+
+func parseResult(variable string, def prv1alpha2.PolicyResult) (prv1alpha2.PolicyResult, error) {
+	r, err := report.ParseResult(env.String(variable, ""), def)
+	if err != nil {
+		return "", fmt.Errorf("%w in %q", err, variable)
 	}
+	return r, nil
+}
 
-	return nil, fmt.Errorf("kubearmor service name variable must %q be set", env.KubeArmorServiceName)
+func newLogClient(i instance.Instance, o klog.Options) (*klog.Feeder, error) {
+	if i.Address == "" {
+		return nil, fmt.Errorf("kubearmor service name variable must %q be set", env.KubeArmorServiceName)
+	}
+	client, err := k8s.ConnectK8sClient()
+	if err != nil {
+		return nil, err
+	}
+	return klog.NewClient(i.Address, o, client.K8sClientset)
 }
@@ -3,12 +3,38 @@ package kubearmor
 import (
 	"time"
 
+	"github.com/bakito/policy-report-publisher/internal/instance"
 	"github.com/bakito/policy-report-publisher/internal/report"
 	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const reportSource = "KubeArmor"
+const (
+	reportSource = "KubeArmor"
+
+	actionBlock = "Block"
+	actionAudit = "Audit"
+	actionAllow = "Allow"
+
+	// defaultPosture is the policy of the alerts of the default posture, if no policy matched
+	defaultPosture = "DefaultPosture"
+)
+
+// lsmEnforcers block the operations of Block policies, other enforcers (e.g. "eBPF Monitor" on nodes without
+// a supported LSM) only observe them
+var lsmEnforcers = map[string]bool{
+	"AppArmor": true,
+	"SELinux":  true,
+	"BPFLSM":   true,
+}
+
+// results are the policy results of the alerts
+type results struct {
+	// allow is the result of alerts with action Allow
+	allow prv1alpha2.PolicyResult
+	// posture is the result of the alerts of the default posture, the result of the action if empty
+	posture prv1alpha2.PolicyResult
+}
 
 type Alert struct {
 	Timestamp     int32     `json:"Timestamp"`
@@ -46,22 +72,17 @@ type Alert struct {
 	Cwd               string `json:"Cwd"`
 }
 
-func (a Alert) toItem() *report.Item {
-	return report.ItemFor("kubearmor", a.NamespaceName, a.PodName, prv1alpha2.PolicyReportResult{
+func (a Alert) toItem(i instance.Instance, r results) *report.Item {
+	result := a.result(r)
+	pr := prv1alpha2.PolicyReportResult{
 		Category: a.Type,
 		Message:  a.Result,
 
 		Severity: a.resultSeverity(),
 		Policy:   a.PolicyName,
-		// PolicyResult has one of the following values:
-		//   - pass: indicates that the policy requirements are met
-		//   - fail: indicates that the policy requirements are not met
-		//   - warn: indicates that the policy requirements and not met, and the policy is not scored
-		//   - error: indicates that the policy could not be evaluated
-		//   - skip: indicates that the policy was not selected based on user inputs or applicability
-		Result: "fail",
-		Scored: true,
-		Source: reportSource,
+		Result:   result,
+		Scored:   report.Scored(result),
+		Source:   reportSource,
 		Timestamp: metav1.Timestamp{
 			Nanos: a.Timestamp,
 		},
@@ -75,7 +96,37 @@ func (a Alert) toItem() *report.Item {
 			"resource":             a.Resource,
 			"cwd":                  a.Cwd,
 		},
-	}, &a)
+	}
+	if a.Action != "" {
+		pr.Properties["action"] = a.Action
+	}
+	if a.Enforcer != "" {
+		pr.Properties["enforcer"] = a.Enforcer
+	}
+	i.AddProperty(pr.Properties)
+	return report.ItemFor("kubearmor", a.NamespaceName, a.PodName, pr, &a).ForInstance(i.Name).OnNode(a.HostName)
+}
+
+// result returns the policy result of the action and enforcer: blocked operations fail, audited operations and
+// operations of Block policies that were not enforced by an LSM warn
+func (a Alert) result(r results) prv1alpha2.PolicyResult {
+	if a.PolicyName == defaultPosture && r.posture != "" {
+		return r.posture
+	}
+	switch a.Action {
+	case actionBlock:
+		if a.Enforcer != "" && !lsmEnforcers[a.Enforcer] {
+			return "warn"
+		}
+		return "fail"
+	case actionAudit:
+		return "warn"
+	case actionAllow:
+		return r.allow
+	default:
+		// alerts without action
+		return "fail"
+	}
 }
 
 func (a Alert) resultSeverity() prv1alpha2.PolicySeverity {
@@ -0,0 +1,72 @@
+package kubearmor
+
+import (
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+func TestAlertResult(t *testing.T) {
+	r := results{allow: "pass"}
+	tests := []struct {
+		name    string
+		alert   Alert
+		results results
+		want    prv1alpha2.PolicyResult
+	}{
+		{name: "blocked by AppArmor", alert: Alert{Action: actionBlock, Enforcer: "AppArmor"}, results: r, want: "fail"},
+		{name: "blocked by BPFLSM", alert: Alert{Action: actionBlock, Enforcer: "BPFLSM"}, results: r, want: "fail"},
+		{name: "block observed by eBPF Monitor", alert: Alert{Action: actionBlock, Enforcer: "eBPF Monitor"}, results: r, want: "warn"},
+		{name: "block without enforcer", alert: Alert{Action: actionBlock}, results: r, want: "fail"},
+		{name: "audit", alert: Alert{Action: actionAudit, Enforcer: "eBPF Monitor"}, results: r, want: "warn"},
+		{name: "allow", alert: Alert{Action: actionAllow}, results: results{allow: "skip"}, want: "skip"},
+		{name: "no action", alert: Alert{}, results: r, want: "fail"},
+		{
+			name:    "default posture",
+			alert:   Alert{Action: actionBlock, Enforcer: "AppArmor", PolicyName: defaultPosture},
+			results: results{allow: "pass", posture: "warn"},
+			want:    "warn",
+		},
+		{
+			name:    "default posture without posture result",
+			alert:   Alert{Action: actionBlock, Enforcer: "AppArmor", PolicyName: defaultPosture},
+			results: r,
+			want:    "fail",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.alert.result(tt.results); got != tt.want {
+				t.Errorf("result() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		def     prv1alpha2.PolicyResult
+		want    prv1alpha2.PolicyResult
+		wantErr bool
+	}{
+		{name: "default", def: "pass", want: "pass"},
+		{name: "empty default", want: ""},
+		{name: "value", value: "warn", def: "pass", want: "warn"},
+		{name: "invalid value", value: "allow", def: "pass", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(env.KubeArmorAllowResult, tt.value)
+			got, err := parseResult(env.KubeArmorAllowResult, tt.def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
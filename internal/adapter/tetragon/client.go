@@ -0,0 +1,85 @@
+package tetragon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var defaultEventTypes = []string{"process_kprobe", "process_tracepoint", "process_uprobe", "process_lsm"}
+
+func Run(ctx context.Context, reportChan chan *report.Item) error {
+	req, err := newRequest()
+	if err != nil {
+		return err
+	}
+
+	target, ok := os.LookupEnv(env.TetragonServiceName)
+	if !ok {
+		return fmt.Errorf("tetragon service name variable must %q be set", env.TetragonServiceName)
+	}
+
+	// the tetragon gRPC API is served in plain text on the node (tcp or unix socket)
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to '%s': %w", target, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	return getEvents(ctx, tetragon.NewFineGuidanceSensorsClient(conn), reportChan, req)
+}
+
+func newRequest() (*tetragon.GetEventsRequest, error) {
+	types := env.List(env.TetragonEventTypes, defaultEventTypes)
+	filter := &tetragon.Filter{}
+	for _, t := range types {
+		et, ok := tetragon.EventType_value[strings.ToUpper(t)]
+		if !ok {
+			return nil, fmt.Errorf("unknown tetragon event type %q in %q", t, env.TetragonEventTypes)
+		}
+		filter.EventSet = append(filter.EventSet, tetragon.EventType(et))
+	}
+	return &tetragon.GetEventsRequest{AllowList: []*tetragon.Filter{filter}}, nil
+}
+
+func getEvents(ctx context.Context, client tetragon.FineGuidanceSensorsClient, reportChan chan *report.Item,
+	req *tetragon.GetEventsRequest,
+) error {
+	b, err := client.GetEvents(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := b.Recv()
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, context.Canceled):
+			return nil
+		case err == nil:
+		default:
+			if status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		}
+
+		if item := toItem(resp); item != nil {
+			select {
+			case reportChan <- item:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tetragon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"google.golang.org/protobuf/encoding/protojson"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const reportSource = "Tetragon"
+
+// enforcingActions are the TracingPolicy actions that block the operation
+var enforcingActions = map[string]bool{
+	"sigkill":  true,
+	"override": true,
+	"signal":   true,
+}
+
+// policyEvent holds the common fields of the events created by a TracingPolicy
+type policyEvent struct {
+	eventType  string
+	process    *tetragon.Process
+	parent     *tetragon.Process
+	hook       string
+	policyName string
+	action     string
+	message    string
+	tags       []string
+	args       []*tetragon.KprobeArgument
+}
+
+func toItem(resp *tetragon.GetEventsResponse) *report.Item {
+	e := toPolicyEvent(resp)
+	if e == nil {
+		return nil
+	}
+	pod := e.process.GetPod()
+	if pod.GetNamespace() == "" || pod.GetName() == "" {
+		// events of processes on the host
+		return nil
+	}
+
+	var result prv1alpha2.PolicyResult = "warn"
+	var severity prv1alpha2.PolicySeverity = "medium"
+	if enforcingActions[e.action] {
+		result = "fail"
+		severity = "high"
+	}
+
+	message := e.message
+	if message == "" {
+		message = fmt.Sprintf("%s %s", e.process.GetBinary(), e.hook)
+	}
+
+	pr := prv1alpha2.PolicyReportResult{
+		Category: e.eventType,
+		Message:  message,
+
+		Severity: severity,
+		Policy:   e.policyName,
+		Rule:     fmt.Sprintf("%s %s", e.hook, e.process.GetBinary()),
+		Result:   result,
+		Scored:   report.Scored(result),
+		Source:   reportSource,
+		Timestamp: metav1.Timestamp{
+			Seconds: resp.GetTime().GetSeconds(),
+			Nanos:   resp.GetTime().GetNanos(),
+		},
+		Properties: map[string]string{
+			report.PropertyCreated: updatedTimeRFC3339(resp),
+			report.PropertyUpdated: updatedTimeRFC3339(resp),
+			"action":               e.action,
+			"binary":               e.process.GetBinary(),
+			"arguments":            e.process.GetArguments(),
+			"cwd":                  e.process.GetCwd(),
+			"parent-binary":        e.parent.GetBinary(),
+			"container":            pod.GetContainer().GetName(),
+			"workload":             pod.GetWorkload(),
+			"node":                 resp.GetNodeName(),
+		},
+	}
+	if len(e.tags) > 0 {
+		pr.Properties["tags"] = strings.Join(e.tags, ",")
+	}
+	for i, arg := range e.args {
+		pr.Properties[argName(i, arg)] = argValue(arg)
+	}
+
+	return report.ItemFor("tetragon", pod.GetNamespace(), pod.GetName(), pr, resp).OnNode(resp.GetNodeName())
+}
+
+func toPolicyEvent(resp *tetragon.GetEventsResponse) *policyEvent {
+	if ev := resp.GetProcessKprobe(); ev != nil {
+		return &policyEvent{
+			eventType: "process_kprobe", process: ev.GetProcess(), parent: ev.GetParent(), hook: ev.GetFunctionName(),
+			policyName: ev.GetPolicyName(), action: actionName(ev.GetAction()), message: ev.GetMessage(),
+			tags: ev.GetTags(), args: ev.GetArgs(),
+		}
+	}
+	if ev := resp.GetProcessTracepoint(); ev != nil {
+		return &policyEvent{
+			eventType: "process_tracepoint", process: ev.GetProcess(), parent: ev.GetParent(),
+			hook: ev.GetSubsys() + "/" + ev.GetEvent(), policyName: ev.GetPolicyName(),
+			action: actionName(ev.GetAction()), message: ev.GetMessage(), tags: ev.GetTags(), args: ev.GetArgs(),
+		}
+	}
+	if ev := resp.GetProcessUprobe(); ev != nil {
+		return &policyEvent{
+			eventType: "process_uprobe", process: ev.GetProcess(), parent: ev.GetParent(),
+			hook: ev.GetPath() + ":" + ev.GetSymbol(), policyName: ev.GetPolicyName(), action: "post",
+			message: ev.GetMessage(), tags: ev.GetTags(), args: ev.GetArgs(),
+		}
+	}
+	if ev := resp.GetProcessLsm(); ev != nil {
+		return &policyEvent{
+			eventType: "process_lsm", process: ev.GetProcess(), parent: ev.GetParent(), hook: ev.GetFunctionName(),
+			policyName: ev.GetPolicyName(), action: actionName(ev.GetAction()), message: ev.GetMessage(),
+			tags: ev.GetTags(), args: ev.GetArgs(),
+		}
+	}
+	if ev := resp.GetProcessExec(); ev != nil {
+		// exec events are not created by a policy
+		return &policyEvent{
+			eventType: "process_exec", process: ev.GetProcess(), parent: ev.GetParent(), hook: "execve",
+			policyName: "process-exec", action: "post",
+		}
+	}
+	return nil
+}
+
+// actionName returns the short name of the action e.g. KPROBE_ACTION_SIGKILL -> sigkill
+func actionName(action tetragon.KprobeAction) string {
+	return strings.ToLower(strings.TrimPrefix(action.String(), "KPROBE_ACTION_"))
+}
+
+func argName(i int, arg *tetragon.KprobeArgument) string {
+	if arg.GetLabel() != "" {
+		return "arg-" + arg.GetLabel()
+	}
+	return fmt.Sprintf("arg-%d", i)
+}
+
+func argValue(arg *tetragon.KprobeArgument) string {
+	b, err := protojson.Marshal(arg)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func updatedTimeRFC3339(resp *tetragon.GetEventsResponse) string {
+	return resp.GetTime().AsTime().Format(time.RFC3339)
+}
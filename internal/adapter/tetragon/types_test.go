@@ -0,0 +1,180 @@
+package tetragon
+
+import (
+	"testing"
+
+	"github.com/cilium/tetragon/api/v1/tetragon"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToItem(t *testing.T) {
+	process := &tetragon.Process{
+		Binary:    "/usr/bin/cat",
+		Arguments: "/etc/shadow",
+		Pod: &tetragon.Pod{
+			Namespace: "default",
+			Name:      "nginx",
+			Container: &tetragon.Container{Name: "app"},
+			Workload:  "nginx",
+		},
+	}
+	parent := &tetragon.Process{Binary: "/bin/sh"}
+	file := []*tetragon.KprobeArgument{{Label: "file", Arg: &tetragon.KprobeArgument_StringArg{StringArg: "/etc/shadow"}}}
+
+	tests := []struct {
+		name         string
+		event        *tetragon.GetEventsResponse
+		wantCategory string
+		wantPolicy   string
+		wantRule     string
+		wantResult   prv1alpha2.PolicyResult
+		wantSeverity prv1alpha2.PolicySeverity
+		wantMessage  string
+	}{
+		{
+			name: "kprobe with sigkill",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessKprobe{ProcessKprobe: &tetragon.ProcessKprobe{
+				Process: process, Parent: parent, FunctionName: "security_file_open", PolicyName: "file-monitoring",
+				Action: tetragon.KprobeAction_KPROBE_ACTION_SIGKILL, Message: "sensitive file opened", Args: file,
+			}}},
+			wantCategory: "process_kprobe",
+			wantPolicy:   "file-monitoring",
+			wantRule:     "security_file_open /usr/bin/cat",
+			wantResult:   "fail",
+			wantSeverity: "high",
+			wantMessage:  "sensitive file opened",
+		},
+		{
+			name: "kprobe with post",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessKprobe{ProcessKprobe: &tetragon.ProcessKprobe{
+				Process: process, Parent: parent, FunctionName: "security_file_open", PolicyName: "file-monitoring",
+				Action: tetragon.KprobeAction_KPROBE_ACTION_POST,
+			}}},
+			wantCategory: "process_kprobe",
+			wantPolicy:   "file-monitoring",
+			wantRule:     "security_file_open /usr/bin/cat",
+			wantResult:   "warn",
+			wantSeverity: "medium",
+			wantMessage:  "/usr/bin/cat security_file_open",
+		},
+		{
+			name: "tracepoint with override",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessTracepoint{ProcessTracepoint: &tetragon.ProcessTracepoint{
+				Process: process, Parent: parent, Subsys: "syscalls", Event: "sys_enter_openat", PolicyName: "openat",
+				Action: tetragon.KprobeAction_KPROBE_ACTION_OVERRIDE,
+			}}},
+			wantCategory: "process_tracepoint",
+			wantPolicy:   "openat",
+			wantRule:     "syscalls/sys_enter_openat /usr/bin/cat",
+			wantResult:   "fail",
+			wantSeverity: "high",
+			wantMessage:  "/usr/bin/cat syscalls/sys_enter_openat",
+		},
+		{
+			name: "uprobe",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessUprobe{ProcessUprobe: &tetragon.ProcessUprobe{
+				Process: process, Parent: parent, Path: "/usr/bin/bash", Symbol: "readline", PolicyName: "bash-readline",
+			}}},
+			wantCategory: "process_uprobe",
+			wantPolicy:   "bash-readline",
+			wantRule:     "/usr/bin/bash:readline /usr/bin/cat",
+			wantResult:   "warn",
+			wantSeverity: "medium",
+			wantMessage:  "/usr/bin/cat /usr/bin/bash:readline",
+		},
+		{
+			name: "lsm with signal",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessLsm{ProcessLsm: &tetragon.ProcessLsm{
+				Process: process, Parent: parent, FunctionName: "file_open", PolicyName: "lsm-file-open",
+				Action: tetragon.KprobeAction_KPROBE_ACTION_SIGNAL,
+			}}},
+			wantCategory: "process_lsm",
+			wantPolicy:   "lsm-file-open",
+			wantRule:     "file_open /usr/bin/cat",
+			wantResult:   "fail",
+			wantSeverity: "high",
+			wantMessage:  "/usr/bin/cat file_open",
+		},
+		{
+			name: "exec",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessExec{ProcessExec: &tetragon.ProcessExec{
+				Process: process, Parent: parent,
+			}}},
+			wantCategory: "process_exec",
+			wantPolicy:   "process-exec",
+			wantRule:     "execve /usr/bin/cat",
+			wantResult:   "warn",
+			wantSeverity: "medium",
+			wantMessage:  "/usr/bin/cat execve",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.event.NodeName = "node-1"
+			tt.event.Time = &timestamppb.Timestamp{Seconds: 1714557600}
+
+			item := toItem(tt.event)
+			if item == nil {
+				t.Fatal("toItem() = nil")
+			}
+			if item.Namespace != "default" || item.Name != "nginx" {
+				t.Errorf("item = %s/%s, want default/nginx", item.Namespace, item.Name)
+			}
+			r := item.Result()
+			if r.Category != tt.wantCategory || r.Policy != tt.wantPolicy || r.Rule != tt.wantRule {
+				t.Errorf("category, policy, rule = %q, %q, %q, want %q, %q, %q",
+					r.Category, r.Policy, r.Rule, tt.wantCategory, tt.wantPolicy, tt.wantRule)
+			}
+			if r.Result != tt.wantResult || r.Severity != tt.wantSeverity {
+				t.Errorf("result, severity = %q, %q, want %q, %q", r.Result, r.Severity, tt.wantResult, tt.wantSeverity)
+			}
+			if r.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", r.Message, tt.wantMessage)
+			}
+			if r.Properties["container"] != "app" || r.Properties["parent-binary"] != "/bin/sh" || r.Properties["node"] != "node-1" {
+				t.Errorf("properties = %v", r.Properties)
+			}
+		})
+	}
+}
+
+func TestToItemWithoutPod(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *tetragon.GetEventsResponse
+	}{
+		{
+			name: "process on the host",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessKprobe{ProcessKprobe: &tetragon.ProcessKprobe{
+				Process: &tetragon.Process{Binary: "/usr/bin/cat"}, FunctionName: "security_file_open",
+				Action: tetragon.KprobeAction_KPROBE_ACTION_SIGKILL,
+			}}},
+		},
+		{
+			name: "pod without name",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessLsm{ProcessLsm: &tetragon.ProcessLsm{
+				Process: &tetragon.Process{Pod: &tetragon.Pod{Namespace: "default"}},
+			}}},
+		},
+		{
+			name:  "event without process",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessTracepoint{ProcessTracepoint: &tetragon.ProcessTracepoint{}}},
+		},
+		{
+			name:  "other event",
+			event: &tetragon.GetEventsResponse{Event: &tetragon.GetEventsResponse_ProcessExit{ProcessExit: &tetragon.ProcessExit{}}},
+		},
+		{
+			name:  "empty response",
+			event: &tetragon.GetEventsResponse{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if item := toItem(tt.event); item != nil {
+				t.Errorf("toItem() = %v, want nil", item)
+			}
+		})
+	}
+}
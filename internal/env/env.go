@@ -1,18 +1,129 @@
 package env
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	LogReports       = "LOG_REPORTS"
+	LogReports = "LOG_REPORTS"
+	// PublisherName is the name of an independent publisher install, it separates its reports, results, metrics and lease
+	PublisherName    = "PUBLISHER_NAME"
 	LeaderElectionNS = "LEADER_ELECTION_NAMESPACE"
+	HAMode           = "HA_MODE"
 
+	LeaderElectionLeaseName     = "LEADER_ELECTION_LEASE_NAME"
+	LeaderElectionIdentity      = "LEADER_ELECTION_IDENTITY"
+	LeaderElectionLeaseDuration = "LEADER_ELECTION_LEASE_DURATION"
+	LeaderElectionRenewDeadline = "LEADER_ELECTION_RENEW_DEADLINE"
+	LeaderElectionRetryPeriod   = "LEADER_ELECTION_RETRY_PERIOD"
+
+	ShutdownTimeout = "SHUTDOWN_TIMEOUT"
+
+	QueueSize           = "QUEUE_SIZE"
+	QueueOverflowPolicy = "QUEUE_OVERFLOW_POLICY"
+	QueueSampleRate     = "QUEUE_SAMPLE_RATE"
+	ReportWorkers       = "REPORT_WORKERS"
+
+	KubeAPIQPS   = "KUBE_API_QPS"
+	KubeAPIBurst = "KUBE_API_BURST"
+
+	// RemoteClusterKubeconfigs are the kubeconfigs of the remote clusters the reports are written to: <cluster>=<path>,...
+	RemoteClusterKubeconfigs = "REMOTE_CLUSTER_KUBECONFIGS"
+
+	// HubbleServiceName is the address of the relay, or named relays <name>=<address>,...
+	// The Hubble and KubeArmor variables can be set per named instance with the suffix _<NAME>.
 	HubbleServiceName = "HUBBLE_SERVICE"
 	HubbleInsecure    = "HUBBLE_INSECURE"
+	// HubbleTLSCA is the CA file to verify the relay certificate, the certificate is not verified if not set
+	HubbleTLSCA = "HUBBLE_TLS_CA"
+	// HubbleTLSServerName is the server name of the relay certificate
+	HubbleTLSServerName = "HUBBLE_TLS_SERVER_NAME"
+	// HubbleNamespaces restricts the flows to the pods of the namespaces
+	HubbleNamespaces = "HUBBLE_NAMESPACES"
+	// HubbleDefaultPolicy is the policy of the results whose denying policy is unknown
+	HubbleDefaultPolicy = "HUBBLE_DEFAULT_POLICY"
+	// HubblePolicyLabels enables the lookup of the denying policies to add their labels
+	HubblePolicyLabels = "HUBBLE_POLICY_LABELS"
+	// HubbleInfrastructureDrops defines how drops that are no policy violations are handled: report (default) or ignore
+	HubbleInfrastructureDrops = "HUBBLE_INFRASTRUCTURE_DROPS"
+	// HubbleFQDNCache enables naming world destinations by the DNS answers observed for the source pod
+	HubbleFQDNCache = "HUBBLE_FQDN_CACHE"
+	// HubbleFQDNCacheMinTTL is the minimal time a name of a DNS answer is kept
+	HubbleFQDNCacheMinTTL = "HUBBLE_FQDN_CACHE_MIN_TTL"
+	// HubbleAggregate enables the aggregation of world addresses and ephemeral ports in the rules
+	HubbleAggregate = "HUBBLE_AGGREGATE"
+	// HubbleAggregateCIDRs are the named cidrs world addresses are collapsed into: <name>=<cidr>,...
+	HubbleAggregateCIDRs = "HUBBLE_AGGREGATE_CIDRS"
+	// HubbleAggregatePrefix is the prefix length of the networks other IPv4 addresses are collapsed into
+	HubbleAggregatePrefix = "HUBBLE_AGGREGATE_PREFIX"
+	// HubbleEphemeralPorts is the range of ephemeral ports that are collapsed: <from>-<to> or none
+	HubbleEphemeralPorts = "HUBBLE_EPHEMERAL_PORTS"
+	// HubbleServices enables naming destination pods by their services
+	HubbleServices = "HUBBLE_SERVICES"
+	// HubbleSuggestPolicy adds an egress rule that would allow the flow to the results: cilium or kubernetes
+	HubbleSuggestPolicy = "HUBBLE_SUGGEST_POLICY"
+	// HubbleClusterName is the name of the local cluster, flows of other clusters are ignored unless they have a remote kubeconfig
+	HubbleClusterName = "HUBBLE_CLUSTER_NAME"
 
 	KubeArmorServiceName = "KUBE_ARMOR_SERVICE"
+	KubeArmorNodeLocal   = "KUBE_ARMOR_NODE_LOCAL"
+	// KubeArmorTLS enables TLS for the connection to the relay
+	KubeArmorTLS = "KUBE_ARMOR_TLS"
+	// KubeArmorTLSCertPath is the path of the certificates of the TLS connection
+	KubeArmorTLSCertPath = "KUBE_ARMOR_TLS_CERT_PATH"
+	// KubeArmorNamespaces restricts the alerts to the pods of the namespaces
+	KubeArmorNamespaces = "KUBE_ARMOR_NAMESPACES"
+	// KubeArmorAllowResult is the result of the alerts with action Allow
+	KubeArmorAllowResult = "KUBE_ARMOR_ALLOW_RESULT"
+	// KubeArmorPostureResult is the result of the alerts of the default posture, the result of the action if not set
+	KubeArmorPostureResult = "KUBE_ARMOR_POSTURE_RESULT"
+
+	FalcoWebhookAddress = "FALCO_WEBHOOK_ADDRESS"
+	// FalcoWebhookToken is the bearer token the Falco requests must send, requests are not authenticated if not set
+	FalcoWebhookToken       = "FALCO_WEBHOOK_TOKEN"
+	FalcoWebhookTLSCert     = "FALCO_WEBHOOK_TLS_CERT"
+	FalcoWebhookTLSKey      = "FALCO_WEBHOOK_TLS_KEY"
+	FalcoWebhookTLSClientCA = "FALCO_WEBHOOK_TLS_CLIENT_CA"
+
+	TetragonServiceName = "TETRAGON_SERVICE"
+	TetragonEventTypes  = "TETRAGON_EVENT_TYPES"
+
+	AuditWebhookAddress = "AUDIT_WEBHOOK_ADDRESS"
+	AuditWebhookTLSCert = "AUDIT_WEBHOOK_TLS_CERT"
+	AuditWebhookTLSKey  = "AUDIT_WEBHOOK_TLS_KEY"
+	// AuditWebhookTLSClientCA is the CA the client certificate of the kube-apiserver is verified with
+	AuditWebhookTLSClientCA = "AUDIT_WEBHOOK_TLS_CLIENT_CA"
+	// AuditWebhookToken is the bearer token the kube-apiserver must send, requests are not authenticated if not set
+	AuditWebhookToken = "AUDIT_WEBHOOK_TOKEN"
+
+	KubeEventsReasons       = "KUBE_EVENTS_REASONS"
+	KubeEventsInvolvedKinds = "KUBE_EVENTS_INVOLVED_KINDS"
+	KubeEventsTypes         = "KUBE_EVENTS_TYPES"
+
+	IngestWebhookAddress = "INGEST_WEBHOOK_ADDRESS"
+	IngestWebhookTLSCert = "INGEST_WEBHOOK_TLS_CERT"
+	IngestWebhookTLSKey  = "INGEST_WEBHOOK_TLS_KEY"
+	// IngestWebhookTLSClientCA is the CA the client certificates of the senders are verified with
+	IngestWebhookTLSClientCA = "INGEST_WEBHOOK_TLS_CLIENT_CA"
+	IngestConfig             = "INGEST_CONFIG"
+	IngestBearerToken        = "INGEST_BEARER_TOKEN"
+
+	EnvoyALSAddress = "ENVOY_ALS_ADDRESS"
+
+	ExternalAdapterAddress = "EXTERNAL_ADAPTER_ADDRESS"
+	// ExternalAdapterNames are the names of the external adapters allowed to connect
+	ExternalAdapterNames = "EXTERNAL_ADAPTER_NAMES"
+	// ExternalAdapterToken is the bearer token the external adapters must send, calls are not authenticated if not set
+	ExternalAdapterToken = "EXTERNAL_ADAPTER_TOKEN"
+	// ExternalAdapterConfigPrefix is the prefix of the settings sent to an external adapter: EXTERNAL_ADAPTER_CONFIG_<NAME>_<KEY>
+	ExternalAdapterConfigPrefix = "EXTERNAL_ADAPTER_CONFIG_"
+
+	// NodeName is the name of the node the publisher runs on, set by the downward API
+	NodeName = "NODE_NAME"
 )
 
 func Active(env string) bool {
@@ -25,3 +136,61 @@ func Active(env string) bool {
 func Empty(env string) bool {
 	return strings.TrimSpace(os.Getenv(env)) == ""
 }
+
+// Duration returns the duration of the variable or the default value if it is not set
+func Duration(env string, def time.Duration) (time.Duration, error) {
+	if Empty(env) {
+		return def, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(os.Getenv(env)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %q: %w", env, err)
+	}
+	return d, nil
+}
+
+// Int returns the int value of the variable or the default value if it is not set
+func Int(env string, def int) (int, error) {
+	if Empty(env) {
+		return def, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(os.Getenv(env)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid int in %q: %w", env, err)
+	}
+	return i, nil
+}
+
+// Float returns the float value of the variable or the default value if it is not set
+func Float(env string, def float64) (float64, error) {
+	if Empty(env) {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(env)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float in %q: %w", env, err)
+	}
+	return f, nil
+}
+
+// String returns the trimmed value of the variable or the default value if it is not set
+func String(env string, def string) string {
+	if Empty(env) {
+		return def
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+// List returns the comma separated values of the variable or the default values if it is not set
+func List(env string, def []string) []string {
+	if Empty(env) {
+		return def
+	}
+	var values []string
+	for v := range strings.SplitSeq(os.Getenv(env), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	unixPrefix       = "unix://"
+	authorizationKey = "authorization"
+)
+
+// Serve runs a gRPC server for an adapter until ctx is done. The address is a TCP address or a unix socket (unix://<path>).
+// It only returns once all active streams are completed, so the streams must stop sending to the report channel when ctx is done.
+func Serve(ctx context.Context, name string, address string, register func(s *grpc.Server), opts ...grpc.ServerOption) error {
+	lis, err := listen(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer(opts...)
+	register(srv)
+
+	errChan := make(chan error, 1)
+	go func() {
+		slog.InfoContext(ctx, "starting grpc server", "name", name, "address", address)
+		errChan <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		srv.GracefulStop()
+		return nil
+	}
+}
+
+func listen(ctx context.Context, address string) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if path, ok := strings.CutPrefix(address, unixPrefix); ok {
+		// remove the socket of a previous run
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return lc.Listen(ctx, "unix", path)
+	}
+	return lc.Listen(ctx, "tcp", address)
+}
+
+// Authorize returns the server options that reject the calls that do not send the token as metadata
+// `authorization: Bearer <token>`. If the token is empty, no options are returned.
+func Authorize(token string) []grpc.ServerOption {
+	if token == "" {
+		return nil
+	}
+	authorized := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, a := range md.Get(authorizationKey) {
+			if subtle.ConstantTimeCompare([]byte(a), []byte("Bearer "+token)) == 1 {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler,
+		) (any, error) {
+			if err := authorized(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler,
+		) error {
+			if err := authorized(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
@@ -0,0 +1,119 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// PropertyInstance is the property of the results with the name of the instance
+const PropertyInstance = "instance"
+
+var (
+	// initialBackoff is the delay before a failed instance is restarted, it is doubled with every failure up to maxBackoff
+	initialBackoff = time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+// Instance is an upstream of an adapter. Adapters with a single unnamed upstream have an instance without name.
+type Instance struct {
+	Name    string
+	Address string
+}
+
+// Parse returns the instances of the variable: a single address, or named addresses <name>=<address>,...
+func Parse(variable string) ([]Instance, error) {
+	values := env.List(variable, nil)
+	if len(values) == 1 && !strings.Contains(values[0], "=") {
+		return []Instance{{Address: values[0]}}, nil
+	}
+
+	names := map[string]bool{}
+	var instances []Instance
+	for _, v := range values {
+		name, address, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(address) == "" {
+			return nil, fmt.Errorf("invalid instance %q in %q, must be <name>=<address>", v, variable)
+		}
+		name = strings.TrimSpace(name)
+		if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid instance name %q in %q: %s", name, variable, strings.Join(errs, ", "))
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate instance name %q in %q", name, variable)
+		}
+		names[name] = true
+		instances = append(instances, Instance{Name: name, Address: strings.TrimSpace(address)})
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instance defined in %q", variable)
+	}
+	return instances, nil
+}
+
+// Var returns the variable of the instance <variable>_<NAME> if it is set, or the shared variable otherwise
+func (i Instance) Var(variable string) string {
+	if i.Name == "" {
+		return variable
+	}
+	v := variable + "_" + strings.ToUpper(strings.ReplaceAll(i.Name, "-", "_"))
+	if _, ok := os.LookupEnv(v); ok {
+		return v
+	}
+	return variable
+}
+
+// AddProperty adds the name of the instance to the properties
+func (i Instance) AddProperty(properties map[string]string) {
+	if i.Name != "" {
+		properties[PropertyInstance] = i.Name
+	}
+}
+
+// Run runs all instances concurrently. A single unnamed instance is run once and its error is returned.
+// Named instances are restarted with a backoff until ctx is done, so a failing instance does not stop the others.
+func Run(ctx context.Context, instances []Instance, run func(ctx context.Context, i Instance) error) error {
+	if len(instances) == 1 && instances[0].Name == "" {
+		return run(ctx, instances[0])
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range instances {
+		wg.Go(func() { runWithBackoff(ctx, i, run) })
+	}
+	wg.Wait()
+	return nil
+}
+
+func runWithBackoff(ctx context.Context, i Instance, run func(ctx context.Context, i Instance) error) {
+	backoff := initialBackoff
+	for {
+		slog.InfoContext(ctx, "starting instance", "instance", i.Name, "address", i.Address)
+		started := time.Now()
+		err := run(ctx, i)
+		if ctx.Err() != nil {
+			return
+		}
+		// an instance that ran for a while starts over with the initial backoff
+		if time.Since(started) > maxBackoff {
+			backoff = initialBackoff
+		}
+		slog.ErrorContext(ctx, "instance stopped, restarting", "instance", i.Name, "error", err, "backoff", backoff)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
@@ -0,0 +1,109 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testVariable = "TEST_INSTANCE_SERVICE"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Instance
+		wantErr bool
+	}{
+		{name: "single address", value: "hubble-relay:443", want: []Instance{{Address: "hubble-relay:443"}}},
+		{
+			name:  "named addresses",
+			value: "pool-a=relay-a:443, pool-b = relay-b:443",
+			want:  []Instance{{Name: "pool-a", Address: "relay-a:443"}, {Name: "pool-b", Address: "relay-b:443"}},
+		},
+		{name: "single named address", value: "pool-a=relay-a:443", want: []Instance{{Name: "pool-a", Address: "relay-a:443"}}},
+		{name: "unnamed address in a list", value: "pool-a=relay-a:443,relay-b:443", wantErr: true},
+		{name: "missing address", value: "pool-a=", wantErr: true},
+		{name: "invalid name", value: "Pool_A=relay-a:443", wantErr: true},
+		{name: "duplicate name", value: "pool-a=relay-a:443,pool-a=relay-b:443", wantErr: true},
+		{name: "not set", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(testVariable, tt.value)
+			got, err := Parse(testVariable)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	t.Setenv("TEST_INSTANCE_INSECURE_POOL_B", "true")
+	tests := []struct {
+		name     string
+		instance Instance
+		want     string
+	}{
+		{name: "unnamed instance", instance: Instance{}, want: "TEST_INSTANCE_INSECURE"},
+		{name: "instance variable set", instance: Instance{Name: "pool-b"}, want: "TEST_INSTANCE_INSECURE_POOL_B"},
+		{name: "instance variable not set", instance: Instance{Name: "pool-a"}, want: "TEST_INSTANCE_INSECURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.instance.Var("TEST_INSTANCE_INSECURE"); got != tt.want {
+				t.Errorf("Var() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	initialBackoff, maxBackoff = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { initialBackoff, maxBackoff = time.Second, 5*time.Minute })
+
+	t.Run("single instance returns its error", func(t *testing.T) {
+		err := Run(t.Context(), []Instance{{Address: "relay:443"}}, func(context.Context, Instance) error {
+			return errors.New("failed")
+		})
+		if err == nil {
+			t.Error("Run() error = nil, want the error of the instance")
+		}
+	})
+
+	t.Run("failing instance is restarted without stopping the others", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		var failures atomic.Int32
+		healthyStopped := make(chan struct{})
+		err := Run(ctx, []Instance{{Name: "healthy"}, {Name: "failing"}}, func(ctx context.Context, i Instance) error {
+			if i.Name == "healthy" {
+				<-ctx.Done()
+				close(healthyStopped)
+				return nil
+			}
+			if failures.Add(1) == 3 {
+				cancel()
+			}
+			return errors.New("failed")
+		})
+		if err != nil {
+			t.Errorf("Run() error = %v, want nil", err)
+		}
+		if got := failures.Load(); got != 3 {
+			t.Errorf("failing instance ran %d times, want 3", got)
+		}
+		select {
+		case <-healthyStopped:
+		default:
+			t.Error("healthy instance was not stopped by the context")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// leaderState is 0 if leader election is disabled, 1 for a candidate and 2 for the leader
+	leaderState atomic.Int32
+
+	leaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "leader",
+		Namespace: Namespace,
+		Help:      "1 if this instance is the leader, 0 otherwise",
+	})
+	leaderRegistered atomic.Bool
+)
+
+// SetLeader records the leader status of this instance
+func SetLeader(leader bool) {
+	if leaderRegistered.CompareAndSwap(false, true) {
+		prometheus.MustRegister(leaderGauge)
+	}
+	if leader {
+		leaderState.Store(2)
+		leaderGauge.Set(1)
+	} else {
+		leaderState.Store(1)
+		leaderGauge.Set(0)
+	}
+}
+
+// leaderStatus returns the leader status for the health endpoint, empty if leader election is disabled
+func leaderStatus() string {
+	switch leaderState.Load() {
+	case 1:
+		return "candidate"
+	case 2:
+		return "leader"
+	default:
+		return ""
+	}
+}
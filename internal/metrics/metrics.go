@@ -5,15 +5,39 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/bakito/policy-report-publisher/version"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const (
+	// LabelPublisher is the label of the metrics of a named publisher install
+	LabelPublisher = "publisher"
+	// LabelInstance is the label of the item metrics with the instance of the adapter, empty for a single instance
+	LabelInstance = "instance"
+)
+
+// Namespace is the prefix of all metrics of the publisher
+var Namespace = strings.ReplaceAll(version.Name, "-", "_")
+
+// WithPublisher adds the publisher label to all metrics registered afterwards, if the name is set
+func WithPublisher(name string) {
+	if name != "" {
+		prometheus.DefaultRegisterer = prometheus.WrapRegistererWith(prometheus.Labels{LabelPublisher: name},
+			prometheus.DefaultRegisterer)
+	}
+}
+
 func Start(ctx context.Context) {
 	http.Handle("/metrics", promhttp.Handler())
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = fmt.Fprintln(w, "OK")
+		if status := leaderStatus(); status != "" {
+			_, _ = fmt.Fprintln(w, "leader-election:", status)
+		}
 	})
 
 	slog.InfoContext(ctx, "starting metrics", "port", "8080")
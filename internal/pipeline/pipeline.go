@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/metrics"
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultQueueSize  = 100
+	defaultSampleRate = 10
+	defaultWorkers    = 4
+)
+
+// RunFunc runs an adapter and sends its report items to the report channel until ctx is done
+type RunFunc func(ctx context.Context, reportChan chan *report.Item) error
+
+type adapter struct {
+	name       string
+	serviceVar string
+	run        RunFunc
+	// push adapters receive their items (e.g. by a webhook behind a Service), each item on one replica only
+	push bool
+}
+
+// Pipeline feeds the items of the enabled adapters into the report handler.
+// On shutdown the adapters are stopped first, then the queued items are flushed
+// within the shutdown timeout before the surrounding context (and lease) is released.
+type Pipeline struct {
+	stopCtx         context.Context //nolint:containedctx // signals the shutdown of every run
+	shutdownTimeout time.Duration
+	queueSize       int
+	overflow        OverflowPolicy
+	sampleRate      int
+	workers         int
+	adapters        []adapter
+	lost            *prometheus.CounterVec
+	dropped         *prometheus.CounterVec
+	skipped         *prometheus.CounterVec
+	running         sync.Mutex
+}
+
+func New(stopCtx context.Context, shutdownTimeout time.Duration) (*Pipeline, error) {
+	p := &Pipeline{stopCtx: stopCtx, shutdownTimeout: shutdownTimeout}
+	var err error
+	if p.queueSize, err = env.Int(env.QueueSize, defaultQueueSize); err != nil {
+		return nil, err
+	}
+	if p.queueSize < 1 {
+		return nil, fmt.Errorf("%q must be positive", env.QueueSize)
+	}
+	if p.overflow, err = parseOverflowPolicy(env.String(env.QueueOverflowPolicy, string(OverflowBlock))); err != nil {
+		return nil, err
+	}
+	if p.sampleRate, err = env.Int(env.QueueSampleRate, defaultSampleRate); err != nil {
+		return nil, err
+	}
+	if p.sampleRate < 1 {
+		return nil, fmt.Errorf("%q must be positive", env.QueueSampleRate)
+	}
+	if p.workers, err = env.Int(env.ReportWorkers, defaultWorkers); err != nil {
+		return nil, err
+	}
+	if p.workers < 1 {
+		return nil, fmt.Errorf("%q must be positive", env.ReportWorkers)
+	}
+
+	p.lost = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "items_lost_on_shutdown",
+			Namespace: metrics.Namespace,
+			Help:      "The number of items that could not be flushed on shutdown by adapter",
+		},
+		[]string{"adapter", metrics.LabelInstance},
+	)
+
+	p.dropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "items_dropped",
+			Namespace: metrics.Namespace,
+			Help:      "The number of items dropped by the queue overflow policy by adapter",
+		},
+		[]string{"adapter", metrics.LabelInstance, "policy"},
+	)
+
+	p.skipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "items_skipped",
+			Namespace: metrics.Namespace,
+			Help:      "The number of items skipped by adapter, as another replica is responsible for their namespace",
+		},
+		[]string{"adapter", metrics.LabelInstance},
+	)
+
+	prometheus.MustRegister(p.lost, p.dropped, p.skipped)
+
+	slog.Info("report queue", "size", p.queueSize, "overflow-policy", p.overflow, "workers", p.workers)
+	return p, nil
+}
+
+// Add registers an adapter that is started if the service variable is set
+func (p *Pipeline) Add(name string, serviceVar string, run RunFunc) {
+	p.adapters = append(p.adapters, adapter{name: name, serviceVar: serviceVar, run: run})
+}
+
+// AddPush registers an adapter the items are pushed to, that is started if the service variable is set.
+// A pushed item is received by one replica only, so it is not skipped if another replica is responsible.
+func (p *Pipeline) AddPush(name string, serviceVar string, run RunFunc) {
+	p.adapters = append(p.adapters, adapter{name: name, serviceVar: serviceVar, run: run, push: true})
+}
+
+// Run starts the adapters and processes their items until the pipeline is stopped or ctx is done.
+// If the pipeline was stopped or an adapter failed, cancel is called once all queued items are flushed.
+// If ctx is done (e.g. the leadership was lost), Run returns without calling cancel.
+func (p *Pipeline) Run(ctx context.Context, handler report.Handler, cancel context.CancelFunc) {
+	p.running.Lock()
+	defer p.running.Unlock()
+	defer func() {
+		if ctx.Err() == nil {
+			cancel()
+		}
+	}()
+
+	adapterCtx, stopAdapters := context.WithCancel(ctx)
+	defer stopAdapters()
+	stopOnShutdown := context.AfterFunc(p.stopCtx, stopAdapters)
+	defer stopOnShutdown()
+
+	q := &queue{
+		items:      make(chan *report.Item, p.queueSize),
+		accept:     handler.Responsible,
+		policy:     p.overflow,
+		sampleRate: p.sampleRate,
+		dropped:    p.dropped,
+		skipped:    p.skipped,
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range p.adapters {
+		if !env.Empty(a.serviceVar) {
+			// each adapter gets its own channel, so the overflow policy is applied per adapter
+			reportChan := make(chan *report.Item)
+			wg.Go(func() { q.forward(reportChan, a.push) })
+			wg.Go(func() {
+				defer close(reportChan)
+				slog.InfoContext(ctx, "starting", "name", a.name, "service", os.Getenv(a.serviceVar))
+				if err := a.run(adapterCtx, reportChan); err != nil {
+					slog.ErrorContext(ctx, "run exited with error", "name", a.name, "error", err)
+					stopAdapters()
+				}
+			})
+		}
+	}
+
+	// the queue is closed as soon as no adapter can send anymore
+	go func() {
+		wg.Wait()
+		close(q.items)
+	}()
+
+	p.process(ctx, adapterCtx, handler, q.items)
+}
+
+// Wait blocks until the active run, if any, has flushed its queue
+func (p *Pipeline) Wait() {
+	p.running.Lock()
+	defer p.running.Unlock()
+}
+
+func (p *Pipeline) process(ctx context.Context, adapterCtx context.Context, handler report.Handler, reportChan chan *report.Item) {
+	// in-flight updates must not be aborted by the shutdown, they are bound by the shutdown timeout instead
+	updateCtx, cancelUpdates := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelUpdates()
+	stopping := adapterCtx.Done()
+
+	w := p.startWorkers(ctx, updateCtx, handler)
+
+	for {
+		select {
+		case <-stopping:
+			stopping = nil
+			slog.InfoContext(ctx, "Adapters stopped, flushing queued reports.",
+				"queued", len(reportChan), "timeout", p.shutdownTimeout)
+			deadline := time.AfterFunc(p.shutdownTimeout, cancelUpdates)
+			defer deadline.Stop()
+		case <-updateCtx.Done():
+			p.discard(ctx, reportChan)
+			w.stop()
+			return
+		case rep, ok := <-reportChan:
+			if !ok {
+				// Channel closed, wait for the workers to process the dispatched items
+				w.stop()
+				slog.InfoContext(ctx, "Report queue flushed, exiting report processing loop.")
+				return
+			}
+			if !w.dispatch(updateCtx, rep) {
+				p.lost.WithLabelValues(rep.HandlerID(), rep.Instance()).Inc()
+			}
+		}
+	}
+}
+
+// discard drops all queued items. It does not wait for adapters that did not stop within the shutdown timeout,
+// their items are not counted.
+func (p *Pipeline) discard(ctx context.Context, reportChan chan *report.Item) {
+	lost := 0
+	for {
+		select {
+		case rep, ok := <-reportChan:
+			if ok {
+				p.lost.WithLabelValues(rep.HandlerID(), rep.Instance()).Inc()
+				lost++
+				continue
+			}
+			slog.WarnContext(ctx, "Shutdown timeout reached, queued reports were discarded.", "lost", lost)
+		default:
+			slog.WarnContext(ctx, "Shutdown timeout reached, queued reports were discarded, not all adapters stopped.",
+				"lost", lost)
+		}
+		return
+	}
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowPolicy defines what happens to an item that is sent by an adapter while the queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until there is space in the queue
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest removes the oldest queued item to make space for the new one
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNewest drops the new item
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowSample waits for space for every nth item of an adapter and drops the others
+	OverflowSample OverflowPolicy = "sample"
+)
+
+func parseOverflowPolicy(value string) (OverflowPolicy, error) {
+	switch p := OverflowPolicy(value); p {
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest, OverflowSample:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown queue overflow policy %q", value)
+	}
+}
+
+// queue is the bounded buffer between the adapters and the report handler
+type queue struct {
+	items      chan *report.Item
+	accept     func(item *report.Item) bool
+	policy     OverflowPolicy
+	sampleRate int
+	dropped    *prometheus.CounterVec
+	skipped    *prometheus.CounterVec
+}
+
+// forward moves the items sent by an adapter into the queue until the adapter channel is closed.
+// Unless the policy is block, the adapter is never stalled by a full queue. The items of push adapters
+// are received by a single replica, they are always accepted.
+func (q *queue) forward(in <-chan *report.Item, push bool) {
+	overflows := 0
+	for item := range in {
+		if !push && !q.accept(item) {
+			q.skipped.WithLabelValues(item.HandlerID(), item.Instance()).Inc()
+			continue
+		}
+		select {
+		case q.items <- item:
+			continue
+		default:
+		}
+
+		switch q.policy {
+		case OverflowDropNewest:
+			q.drop(item)
+		case OverflowDropOldest:
+			q.pushDropOldest(item)
+		case OverflowSample:
+			overflows++
+			if overflows%q.sampleRate == 0 {
+				q.items <- item
+			} else {
+				q.drop(item)
+			}
+		default:
+			q.items <- item
+		}
+	}
+}
+
+func (q *queue) pushDropOldest(item *report.Item) {
+	for {
+		select {
+		case q.items <- item:
+			return
+		default:
+		}
+		select {
+		case oldest := <-q.items:
+			q.drop(oldest)
+		default:
+		}
+	}
+}
+
+func (q *queue) drop(item *report.Item) {
+	q.dropped.WithLabelValues(item.HandlerID(), item.Instance(), string(q.policy)).Inc()
+}
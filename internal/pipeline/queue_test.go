@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    OverflowPolicy
+		wantErr bool
+	}{
+		{value: "block", want: OverflowBlock},
+		{value: "drop-oldest", want: OverflowDropOldest},
+		{value: "drop-newest", want: OverflowDropNewest},
+		{value: "sample", want: OverflowSample},
+		{value: "", wantErr: true},
+		{value: "drop", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseOverflowPolicy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOverflowPolicy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseOverflowPolicy(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueForward(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     OverflowPolicy
+		sampleRate int
+		push       bool
+		items      []string
+		want       []string
+		dropped    float64
+		skipped    float64
+	}{
+		{
+			name:   "block keeps all items",
+			policy: OverflowBlock,
+			items:  []string{"1", "2", "3"},
+			want:   []string{"1", "2", "3"},
+		},
+		{
+			name:    "drop-newest drops the items sent while the queue is full",
+			policy:  OverflowDropNewest,
+			items:   []string{"1", "2", "3", "4"},
+			want:    []string{"1", "2"},
+			dropped: 2,
+		},
+		{
+			name:    "drop-oldest drops the queued items",
+			policy:  OverflowDropOldest,
+			items:   []string{"1", "2", "3", "4"},
+			want:    []string{"3", "4"},
+			dropped: 2,
+		},
+		{
+			name:       "sample keeps every nth overflowing item",
+			policy:     OverflowSample,
+			sampleRate: 3,
+			items:      []string{"1", "2", "3", "4", "5"},
+			want:       []string{"1", "2", "5"},
+			dropped:    2,
+		},
+		{
+			name:    "items that are not accepted are skipped",
+			policy:  OverflowDropNewest,
+			items:   []string{"1", "other", "2", "3"},
+			want:    []string{"1", "2"},
+			dropped: 1,
+			skipped: 1,
+		},
+		{
+			name:    "items of push adapters are always accepted",
+			policy:  OverflowDropNewest,
+			push:    true,
+			items:   []string{"1", "other", "2"},
+			want:    []string{"1", "other"},
+			dropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the queue is only read after the items were forwarded, or once the last item was sent if
+			// the policy blocks, so the overflows do not depend on the reader
+			queuedItems := make(chan *report.Item, 2)
+			var got []string
+			done := make(chan struct{})
+			startReading := sync.OnceFunc(func() {
+				go func() {
+					defer close(done)
+					for item := range queuedItems {
+						got = append(got, item.Name)
+					}
+				}()
+			})
+			last := tt.items[len(tt.items)-1]
+			blocks := tt.policy == OverflowBlock || tt.policy == OverflowSample
+			q := &queue{
+				items: queuedItems,
+				accept: func(item *report.Item) bool {
+					if blocks && item.Name == last {
+						startReading()
+					}
+					return item.Name != "other"
+				},
+				policy:     tt.policy,
+				sampleRate: tt.sampleRate,
+				dropped:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped"}, []string{"adapter", "instance", "policy"}),
+				skipped:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "skipped"}, []string{"adapter", "instance"}),
+			}
+
+			in := make(chan *report.Item, len(tt.items))
+			for _, name := range tt.items {
+				in <- report.ItemFor("test", "default", name, prv1alpha2.PolicyReportResult{}, nil)
+			}
+			close(in)
+
+			q.forward(in, tt.push)
+			startReading()
+			close(q.items)
+			<-done
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("queued items = %v, want %v", got, tt.want)
+			}
+			if dropped := testutil.ToFloat64(q.dropped.WithLabelValues("test", "", string(tt.policy))); dropped != tt.dropped {
+				t.Errorf("dropped = %v, want %v", dropped, tt.dropped)
+			}
+			if skipped := testutil.ToFloat64(q.skipped.WithLabelValues("test", "")); skipped != tt.skipped {
+				t.Errorf("skipped = %v, want %v", skipped, tt.skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+
+	"github.com/bakito/policy-report-publisher/internal/report"
+)
+
+// workers update the reports in parallel. Items are sharded by their target report,
+// so the updates of one report are processed in order and never conflict with each other.
+type workers struct {
+	shards []chan *report.Item
+	wg     sync.WaitGroup
+}
+
+func (p *Pipeline) startWorkers(ctx context.Context, updateCtx context.Context, handler report.Handler) *workers {
+	w := &workers{shards: make([]chan *report.Item, p.workers)}
+	for i := range w.shards {
+		shard := make(chan *report.Item, 1)
+		w.shards[i] = shard
+		w.wg.Go(func() {
+			for rep := range shard {
+				if err := handler.Update(updateCtx, rep); err != nil {
+					if updateCtx.Err() != nil {
+						p.lost.WithLabelValues(rep.HandlerID(), rep.Instance()).Inc()
+						continue
+					}
+					slog.ErrorContext(ctx, "Failed to update report", "error", err)
+				}
+			}
+		})
+	}
+	return w
+}
+
+// dispatch sends the item to the worker of its shard. Returns false if the item could not be
+// dispatched before updateCtx was done.
+func (w *workers) dispatch(updateCtx context.Context, rep *report.Item) bool {
+	select {
+	case w.shards[w.shard(rep)] <- rep:
+		return true
+	case <-updateCtx.Done():
+		return false
+	}
+}
+
+func (w *workers) shard(rep *report.Item) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(rep.String()))
+	return int(h.Sum32() % uint32(len(w.shards))) // #nosec G115 number of workers is positive
+}
+
+// stop waits until the workers processed all dispatched items
+func (w *workers) stop() {
+	for _, shard := range w.shards {
+		close(shard)
+	}
+	w.wg.Wait()
+}
@@ -4,34 +4,109 @@ package report
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/metrics"
 	"github.com/bakito/policy-report-publisher/version"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
-var (
+const (
 	defaultLeaseDuration = 15 * time.Second
 	defaultRenewDeadline = 10 * time.Second
 	defaultRetryPeriod   = 2 * time.Second
 )
 
+type leaderElectionConfig struct {
+	leaseName     string
+	identity      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+}
+
+// leaderElectionConfigFromEnv reads the leader election config. Independent publisher
+// instances in the same namespace must use different lease names.
+func leaderElectionConfigFromEnv() (*leaderElectionConfig, error) {
+	publisher, err := Publisher()
+	if err != nil {
+		return nil, err
+	}
+	defaultLeaseName := version.Name
+	if publisher != "" {
+		defaultLeaseName += "-" + publisher
+	}
+	cfg := &leaderElectionConfig{
+		leaseName: env.String(env.LeaderElectionLeaseName, defaultLeaseName),
+		identity:  env.String(env.LeaderElectionIdentity, ""),
+	}
+	if errs := validation.IsDNS1123Subdomain(cfg.leaseName); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid lease name %q in %q: %s", cfg.leaseName, env.LeaderElectionLeaseName,
+			strings.Join(errs, ", "))
+	}
+
+	if cfg.identity == "" {
+		// Leader id, needs to be unique
+		id, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		cfg.identity = id + "_" + string(uuid.NewUUID())
+	}
+
+	if cfg.leaseDuration, err = env.Duration(env.LeaderElectionLeaseDuration, defaultLeaseDuration); err != nil {
+		return nil, err
+	}
+	if cfg.renewDeadline, err = env.Duration(env.LeaderElectionRenewDeadline, defaultRenewDeadline); err != nil {
+		return nil, err
+	}
+	if cfg.retryPeriod, err = env.Duration(env.LeaderElectionRetryPeriod, defaultRetryPeriod); err != nil {
+		return nil, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+// validate checks the relation of the timings as required by the leader elector
+func (c *leaderElectionConfig) validate() error {
+	if c.retryPeriod <= 0 {
+		return fmt.Errorf("%q must be greater than zero", env.LeaderElectionRetryPeriod)
+	}
+	if c.renewDeadline <= time.Duration(leaderelection.JitterFactor*float64(c.retryPeriod)) {
+		return fmt.Errorf("%q (%s) must be greater than %.1f * %q (%s)",
+			env.LeaderElectionRenewDeadline, c.renewDeadline,
+			leaderelection.JitterFactor, env.LeaderElectionRetryPeriod, c.retryPeriod)
+	}
+	if c.leaseDuration <= c.renewDeadline {
+		return fmt.Errorf("%q (%s) must be greater than %q (%s)",
+			env.LeaderElectionLeaseDuration, c.leaseDuration, env.LeaderElectionRenewDeadline, c.renewDeadline)
+	}
+	return nil
+}
+
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete,namespace="{{.Release.Namespace}}"
 
 func (h *handler) RunAsLeader(ctx context.Context, cancel context.CancelFunc, leaseLockNamespace string,
 	run func(ctx context.Context, handler Handler, cancel context.CancelFunc),
 ) error {
-	// Leader id, needs to be unique
-	id, err := os.Hostname()
+	cfg, err := leaderElectionConfigFromEnv()
 	if err != nil {
 		return err
 	}
-	id = id + "_" + string(uuid.NewUUID())
+	id := cfg.identity
+	slog.InfoContext(ctx, "leader election", "namespace", leaseLockNamespace, "lease", cfg.leaseName,
+		"identity", id, "lease-duration", cfg.leaseDuration, "renew-deadline", cfg.renewDeadline,
+		"retry-period", cfg.retryPeriod)
 
 	// leader election uses the Kubernetes API by writing to a
 	// lock object, which can be a LeaseLock object (preferred),
@@ -41,53 +116,100 @@ func (h *handler) RunAsLeader(ctx context.Context, cancel context.CancelFunc, le
 
 	// we use the Lease lock type since edits to Leases are less common
 	// and fewer objects in the cluster watch "all Leases".
-	lock := &resourcelock.LeaseLock{
+	lock := &acquiringLock{LeaseLock: &resourcelock.LeaseLock{
 		LeaseMeta: metav1.ObjectMeta{
-			Name:      version.Name,
+			Name:      cfg.leaseName,
 			Namespace: leaseLockNamespace,
 		},
 		Client: h.clientset.CoordinationV1(),
 		LockConfig: resourcelock.ResourceLockConfig{
 			Identity: id,
 		},
-	}
+	}}
 
-	// start the leader election code loop
-	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
-		Lock: lock,
-		// IMPORTANT: you MUST ensure that any code you have that
-		// is protected by the lease must terminate **before**
-		// you call cancel. Otherwise, you could have a background
-		// loop still running and another process could
-		// get elected before your background loop finished, violating
-		// the stated goal of the lease.
-		ReleaseOnCancel: true,
-		LeaseDuration:   defaultLeaseDuration,
-		RenewDeadline:   defaultRenewDeadline,
-		RetryPeriod:     defaultRetryPeriod,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(ctx context.Context) {
-				run(ctx, h, cancel)
-			},
-			OnStoppedLeading: func() {
-				// we can do cleanup here, but note that this callback is always called
-				// when the LeaderElector exits, even if it did not start leading.
-				// Therefore, we should check if we actually started leading before
-				// performing any cleanup operations to avoid unexpected behavior.
-				slog.InfoContext(ctx, "leader lost", "identity", id)
-
-				os.Exit(0)
-			},
+	for {
+		metrics.SetLeader(false)
+		lock.acquired.Store(false)
+		// closed when the pipeline of the leadership term is flushed
+		termDone := make(chan struct{})
 
-			OnNewLeader: func(identity string) {
-				// we're notified when new leader elected
-				if identity == id {
-					// I just got the lock
-					return
-				}
-				slog.InfoContext(ctx, "new leader elected", "identity", identity)
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Name: cfg.leaseName,
+			Lock: lock,
+			// IMPORTANT: you MUST ensure that any code you have that
+			// is protected by the lease must terminate **before**
+			// you call cancel. Otherwise, you could have a background
+			// loop still running and another process could
+			// get elected before your background loop finished, violating
+			// the stated goal of the lease.
+			ReleaseOnCancel: true,
+			LeaseDuration:   cfg.leaseDuration,
+			RenewDeadline:   cfg.renewDeadline,
+			RetryPeriod:     cfg.retryPeriod,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					defer close(termDone)
+					metrics.SetLeader(true)
+					// leaderCtx is cancelled when the lease is lost, the pipeline then stops
+					// without cancelling ctx so we can campaign again.
+					run(leaderCtx, h, cancel)
+				},
+				OnStoppedLeading: func() {
+					// this callback is always called when the LeaderElector exits, even if it did not start leading.
+					metrics.SetLeader(false)
+					if ctx.Err() == nil {
+						slog.InfoContext(ctx, "leader lost", "identity", id)
+					}
+				},
+
+				OnNewLeader: func(identity string) {
+					// we're notified when new leader elected
+					if identity == id {
+						// I just got the lock
+						return
+					}
+					slog.InfoContext(ctx, "new leader elected", "identity", identity)
+				},
 			},
-		},
-	})
-	return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		le.Run(ctx)
+		if lock.acquired.Load() {
+			// the term is started in a goroutine once the lease is acquired, a lost term is flushed
+			// before campaigning again
+			<-termDone
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.InfoContext(ctx, "campaigning again for leadership", "identity", id)
+	}
+}
+
+// acquiringLock records if the lease was acquired by this identity
+type acquiringLock struct {
+	*resourcelock.LeaseLock
+	acquired atomic.Bool
+}
+
+func (l *acquiringLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.LeaseLock.Create(ctx, ler)
+	l.record(ler, err)
+	return err
+}
+
+func (l *acquiringLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.LeaseLock.Update(ctx, ler)
+	l.record(ler, err)
+	return err
+}
+
+func (l *acquiringLock) record(ler resourcelock.LeaderElectionRecord, err error) {
+	if err == nil && ler.HolderIdentity == l.Identity() {
+		l.acquired.Store(true)
+	}
 }
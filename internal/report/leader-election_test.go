@@ -0,0 +1,50 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderElectionConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     leaderElectionConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			cfg:  leaderElectionConfig{leaseDuration: defaultLeaseDuration, renewDeadline: defaultRenewDeadline, retryPeriod: defaultRetryPeriod},
+		},
+		{
+			name:    "retry period not positive",
+			cfg:     leaderElectionConfig{leaseDuration: 15 * time.Second, renewDeadline: 10 * time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "renew deadline not greater than the jittered retry period",
+			cfg:     leaderElectionConfig{leaseDuration: 15 * time.Second, renewDeadline: 12 * time.Second, retryPeriod: 10 * time.Second},
+			wantErr: true,
+		},
+		{
+			name: "renew deadline greater than the jittered retry period",
+			cfg:  leaderElectionConfig{leaseDuration: 15 * time.Second, renewDeadline: 13 * time.Second, retryPeriod: 10 * time.Second},
+		},
+		{
+			name:    "lease duration equal to the renew deadline",
+			cfg:     leaderElectionConfig{leaseDuration: 10 * time.Second, renewDeadline: 10 * time.Second, retryPeriod: 2 * time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "lease duration smaller than the renew deadline",
+			cfg:     leaderElectionConfig{leaseDuration: 5 * time.Second, renewDeadline: 10 * time.Second, retryPeriod: 2 * time.Second},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+)
+
+// idSegment matches path segments with numbers, UUIDs or long hex values
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// PathTemplate replaces the segments of a path that look like ids with {id}, so the rules of requests to the same
+// resource type are stable
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if idSegment.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
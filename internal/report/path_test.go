@@ -0,0 +1,28 @@
+package report
+
+import "testing"
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "/", want: "/"},
+		{path: "/api/v1/orders", want: "/api/v1/orders"},
+		{path: "/api/v1/orders/42", want: "/api/v1/orders/{id}"},
+		{path: "/orders/42/items/7", want: "/orders/{id}/items/{id}"},
+		{path: "/users/0b5d6c1e-3f2a-4b8c-9d7e-1a2b3c4d5e6f", want: "/users/{id}"},
+		{path: "/blobs/0123456789abcdef", want: "/blobs/{id}"},
+		{path: "/blobs/0123456789abcde", want: "/blobs/0123456789abcde"},
+		{path: "/v2/api", want: "/v2/api"},
+		{path: "/orders/42/", want: "/orders/{id}/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := PathTemplate(tt.path); got != tt.want {
+				t.Errorf("PathTemplate(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/bakito/policy-report-publisher/internal/env"
-	"github.com/bakito/policy-report-publisher/version"
+	"github.com/bakito/policy-report-publisher/internal/metrics"
 	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
 	clientset "github.com/kyverno/kyverno/pkg/clients/kube"
 	"github.com/prometheus/client_golang/prometheus"
@@ -19,8 +20,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -29,8 +32,16 @@ import (
 const (
 	propCount = "count"
 
+	reportNamePrefix    = "prp-"
+	namespaceReportName = "namespace"
+
+	defaultKubeAPIQPS   = 20
+	defaultKubeAPIBurst = 30
+
 	PropertyCreated = "created"
 	PropertyUpdated = "updated"
+	// PropertyPublisher is the property of the results with the name of the publisher install
+	PropertyPublisher = "publisher"
 )
 
 var PolicyReport = metav1.TypeMeta{Kind: "PolicyReport", APIVersion: prv1alpha2.GroupVersion.String()}
@@ -39,7 +50,7 @@ var PolicyReport = metav1.TypeMeta{Kind: "PolicyReport", APIVersion: prv1alpha2.
 // +kubebuilder:rbac:groups=wgpolicyk8s.io,resources=policyreports,verbs=get;list;watch;create;update;patch
 
 func NewHandler() (Handler, error) {
-	kc, dcl, cs, err := initKubeClient()
+	kc, dcl, cs, err := initKubeClient("")
 	if err != nil {
 		return nil, err
 	}
@@ -47,32 +58,112 @@ func NewHandler() (Handler, error) {
 	counter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name:      "processed_items",
-			Namespace: strings.ReplaceAll(version.Name, "-", "_"),
+			Namespace: metrics.Namespace,
 			Help:      "The number of processed items by adapter",
 		},
-		[]string{"adapter"},
+		[]string{"adapter", metrics.LabelInstance},
 	)
 
 	prometheus.MustRegister(counter)
 
-	return &handler{
+	publisher, err := Publisher()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &handler{
 		client:     kc,
 		discovery:  dcl,
 		clientset:  cs,
 		logReports: env.Active(env.LogReports),
 		counter:    counter,
-	}, nil
+		publisher:  publisher,
+	}
+	kubeconfigs, err := RemoteKubeconfigs()
+	if err != nil {
+		return nil, err
+	}
+	h.remotes = map[string]*handler{}
+	for cluster, kubeconfig := range kubeconfigs {
+		rc, _, _, err := initKubeClient(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the client of cluster %q: %w", cluster, err)
+		}
+		h.remotes[cluster] = &handler{
+			client:     rc,
+			logReports: h.logReports,
+			counter:    counter,
+			cluster:    cluster,
+			publisher:  publisher,
+		}
+	}
+	if env.Active(env.KubeArmorNodeLocal) {
+		// each node writes the reports of its own pods only, so the publishers of different nodes never conflict
+		if h.nodeName = os.Getenv(env.NodeName); h.nodeName == "" {
+			return nil, fmt.Errorf("node name variable %q must be set if %q is active", env.NodeName, env.KubeArmorNodeLocal)
+		}
+	}
+	return h, nil
+}
+
+// Publisher returns the name of the publisher install, empty if there is a single install
+func Publisher() (string, error) {
+	name := env.String(env.PublisherName, "")
+	if name == "" {
+		return "", nil
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+		return "", fmt.Errorf("invalid publisher name %q in %q: %s", name, env.PublisherName, strings.Join(errs, ", "))
+	}
+	return name, nil
 }
 
-func initKubeClient() (client.Client, *discovery.DiscoveryClient, clientset.Interface, error) {
+// RemoteKubeconfigs returns the kubeconfig paths of the remote clusters by cluster name
+func RemoteKubeconfigs() (map[string]string, error) {
+	kubeconfigs := map[string]string{}
+	for _, c := range env.List(env.RemoteClusterKubeconfigs, nil) {
+		cluster, path, ok := strings.Cut(c, "=")
+		if !ok || strings.TrimSpace(cluster) == "" || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid remote cluster %q in %q, must be <cluster>=<kubeconfig>", c, env.RemoteClusterKubeconfigs)
+		}
+		kubeconfigs[strings.TrimSpace(cluster)] = strings.TrimSpace(path)
+	}
+	return kubeconfigs, nil
+}
+
+// RestConfig returns the config of the cluster of the kubeconfig, or of the default config if empty,
+// with the client side rate limit of the API requests. Each client created from it gets its own limiter,
+// so leader election is not throttled by report updates.
+func RestConfig(kubeconfig string) (*rest.Config, error) {
+	restClientGetter := genericclioptions.ConfigFlags{}
+	if kubeconfig != "" {
+		restClientGetter.KubeConfig = &kubeconfig
+	}
+	config, err := restClientGetter.ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	qps, err := env.Float(env.KubeAPIQPS, defaultKubeAPIQPS)
+	if err != nil {
+		return nil, err
+	}
+	burst, err := env.Int(env.KubeAPIBurst, defaultKubeAPIBurst)
+	if err != nil {
+		return nil, err
+	}
+	config.QPS = float32(qps)
+	config.Burst = burst
+	return config, nil
+}
+
+// initKubeClient creates the clients of the cluster of the kubeconfig, or of the default config if empty
+func initKubeClient(kubeconfig string) (client.Client, *discovery.DiscoveryClient, clientset.Interface, error) {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(prv1alpha2.Install(scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme))
 
-	restClientGetter := genericclioptions.ConfigFlags{}
-	rawKubeConfigLoader := restClientGetter.ToRawKubeConfigLoader()
-
-	config, err := rawKubeConfigLoader.ClientConfig()
+	config, err := RestConfig(kubeconfig)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -109,10 +200,21 @@ func (h *handler) PolicyReportAvailable() (bool, error) {
 	return false, nil
 }
 
+func (h *handler) Responsible(*Item) bool {
+	return true
+}
+
 func (h *handler) Update(ctx context.Context, report *Item) error {
-	if report.Name == "" {
+	if report.Name == "" && !report.namespaceScoped {
 		return nil
 	}
+	if report.cluster != h.cluster {
+		remote, ok := h.remotes[report.cluster]
+		if !ok {
+			return fmt.Errorf("no kubeconfig for cluster %q in %q", report.cluster, env.RemoteClusterKubeconfigs)
+		}
+		return remote.Update(ctx, report)
+	}
 	if h.logReports {
 		b, err := json.Marshal(report.source)
 		if err == nil {
@@ -120,16 +222,35 @@ func (h *handler) Update(ctx context.Context, report *Item) error {
 		}
 	}
 
-	pod := &corev1.Pod{}
-	err := h.client.Get(ctx, report.ObjectKey, pod)
-	if err != nil {
-		return err
+	getPolicyReport := func() (*prv1alpha2.PolicyReport, error) {
+		return h.getNamespacePolicyReport(ctx, report)
+	}
+	if h.nodeName != "" && report.node != "" && report.node != h.nodeName {
+		// items observed on a node are only written by the publisher of this node, items of other adapters are not gated
+		return nil
+	}
+	if !report.namespaceScoped {
+		pod := &corev1.Pod{}
+		err := h.client.Get(ctx, report.ObjectKey, pod)
+		if err != nil {
+			return err
+		}
+		getPolicyReport = func() (*prv1alpha2.PolicyReport, error) {
+			return h.getPolicyReport(ctx, report, pod)
+		}
+	}
+
+	if h.publisher != "" {
+		if report.result.Properties == nil {
+			report.result.Properties = map[string]string{}
+		}
+		report.result.Properties[PropertyPublisher] = h.publisher
 	}
 
-	h.counter.WithLabelValues(report.handlerID).Inc()
+	h.counter.WithLabelValues(report.handlerID, report.instance).Inc()
 
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		pol, err := h.getPolicyReport(ctx, report, pod)
+		pol, err := getPolicyReport()
 		if err != nil {
 			return err
 		}
@@ -141,9 +262,34 @@ func (h *handler) Update(ctx context.Context, report *Item) error {
 	})
 }
 
+// getNamespacePolicyReport returns the report for results that are not related to a pod
+func (h *handler) getNamespacePolicyReport(ctx context.Context, report *Item) (*prv1alpha2.PolicyReport, error) {
+	pol := &prv1alpha2.PolicyReport{}
+	name := h.reportName(namespaceReportName)
+	err := h.client.Get(ctx, types.NamespacedName{Namespace: report.Namespace, Name: name}, pol)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			pol = &prv1alpha2.PolicyReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: report.Namespace,
+					Name:      name,
+				},
+			}
+			pol.Scope = &corev1.ObjectReference{
+				Name:       report.Namespace,
+				Kind:       "Namespace",
+				APIVersion: "v1",
+			}
+		} else {
+			return nil, err
+		}
+	}
+
+	return pol, nil
+}
+
 func (h *handler) getPolicyReport(ctx context.Context, report *Item, pod *corev1.Pod) (*prv1alpha2.PolicyReport, error) {
-	podID := string(pod.GetUID())
-	policyID := fmt.Sprintf("prp-%s", podID)
+	policyID := h.reportName(string(pod.GetUID()))
 
 	pol := &prv1alpha2.PolicyReport{}
 	err := h.client.Get(ctx, types.NamespacedName{Namespace: report.Namespace, Name: policyID}, pol)
@@ -172,6 +318,14 @@ func (h *handler) getPolicyReport(ctx context.Context, report *Item, pod *corev1
 	return pol, nil
 }
 
+// reportName returns the name of the report, prefixed with the publisher name of a named install
+func (h *handler) reportName(name string) string {
+	if h.publisher == "" {
+		return reportNamePrefix + name
+	}
+	return reportNamePrefix + h.publisher + "-" + name
+}
+
 func addResult(pol *prv1alpha2.PolicyReport, result prv1alpha2.PolicyReportResult) {
 	found := false
 
@@ -179,6 +333,7 @@ func addResult(pol *prv1alpha2.PolicyReport, result prv1alpha2.PolicyReportResul
 		if res.Source == result.Source && res.Policy == result.Policy && res.Rule == result.Rule {
 			result.Properties = mergeProperties(pol.Results[i], result)
 			pol.Results[i] = result
+			countResult(&pol.Summary, res.Result, -1)
 			found = true
 		}
 	}
@@ -186,7 +341,28 @@ func addResult(pol *prv1alpha2.PolicyReport, result prv1alpha2.PolicyReportResul
 	if !found {
 		pol.Results = append(pol.Results, result)
 	}
-	pol.Summary.Fail++
+	countResult(&pol.Summary, result.Result, 1)
+}
+
+// countResult adds delta to the counter of the result in the summary. The counters do not get negative,
+// as reports written by older versions counted every result as fail.
+func countResult(summary *prv1alpha2.PolicyReportSummary, result prv1alpha2.PolicyResult, delta int) {
+	var counter *int
+	switch result {
+	case "pass":
+		counter = &summary.Pass
+	case "fail":
+		counter = &summary.Fail
+	case "warn":
+		counter = &summary.Warn
+	case "error":
+		counter = &summary.Error
+	case "skip":
+		counter = &summary.Skip
+	default:
+		return
+	}
+	*counter = max(*counter+delta, 0)
 }
 
 func mergeProperties(oldReport prv1alpha2.PolicyReportResult, newReport prv1alpha2.PolicyReportResult) map[string]string {
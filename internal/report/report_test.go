@@ -0,0 +1,114 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+func TestPublisher(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "tenant-a", want: "tenant-a"},
+		{value: "Tenant-A", wantErr: true},
+		{value: "tenant.a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(env.PublisherName, tt.value)
+			got, err := Publisher()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publisher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Publisher() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportName(t *testing.T) {
+	tests := []struct {
+		publisher string
+		name      string
+		want      string
+	}{
+		{name: "4f9c2b1e-uid", want: "prp-4f9c2b1e-uid"},
+		{name: namespaceReportName, want: "prp-namespace"},
+		{publisher: "tenant-a", name: "4f9c2b1e-uid", want: "prp-tenant-a-4f9c2b1e-uid"},
+		{publisher: "tenant-a", name: namespaceReportName, want: "prp-tenant-a-namespace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			h := &handler{publisher: tt.publisher}
+			if got := h.reportName(tt.name); got != tt.want {
+				t.Errorf("reportName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddResult(t *testing.T) {
+	tests := []struct {
+		result  prv1alpha2.PolicyResult
+		counter func(s prv1alpha2.PolicyReportSummary) int
+	}{
+		{result: "pass", counter: func(s prv1alpha2.PolicyReportSummary) int { return s.Pass }},
+		{result: "fail", counter: func(s prv1alpha2.PolicyReportSummary) int { return s.Fail }},
+		{result: "warn", counter: func(s prv1alpha2.PolicyReportSummary) int { return s.Warn }},
+		{result: "error", counter: func(s prv1alpha2.PolicyReportSummary) int { return s.Error }},
+		{result: "skip", counter: func(s prv1alpha2.PolicyReportSummary) int { return s.Skip }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.result), func(t *testing.T) {
+			pol := &prv1alpha2.PolicyReport{}
+			for i, rule := range []string{"rule", "other"} {
+				addResult(pol, testResult(rule, tt.result))
+				if got := tt.counter(pol.Summary); got != i+1 || total(pol.Summary) != i+1 {
+					t.Errorf("summary = %+v, want %s %d", pol.Summary, tt.result, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestAddResultReplaced(t *testing.T) {
+	pol := &prv1alpha2.PolicyReport{}
+	addResult(pol, testResult("rule", "warn"))
+	addResult(pol, testResult("rule", "fail"))
+	addResult(pol, testResult("rule", "fail"))
+
+	if len(pol.Results) != 1 || pol.Results[0].Result != "fail" {
+		t.Fatalf("results = %+v, want the replaced fail result", pol.Results)
+	}
+	if want := (prv1alpha2.PolicyReportSummary{Fail: 1}); pol.Summary != want {
+		t.Errorf("summary = %+v, want %+v", pol.Summary, want)
+	}
+
+	// reports of older versions counted every result as fail
+	pol = &prv1alpha2.PolicyReport{Summary: prv1alpha2.PolicyReportSummary{Fail: 1}}
+	pol.Results = []prv1alpha2.PolicyReportResult{testResult("rule", "warn")}
+	addResult(pol, testResult("rule", "pass"))
+	if want := (prv1alpha2.PolicyReportSummary{Pass: 1, Fail: 1}); pol.Summary != want {
+		t.Errorf("summary = %+v, want %+v", pol.Summary, want)
+	}
+}
+
+func total(s prv1alpha2.PolicyReportSummary) int {
+	return s.Pass + s.Fail + s.Warn + s.Error + s.Skip
+}
+
+func testResult(rule string, result prv1alpha2.PolicyResult) prv1alpha2.PolicyReportResult {
+	return prv1alpha2.PolicyReportResult{
+		Source:     "test",
+		Policy:     "policy",
+		Rule:       rule,
+		Result:     result,
+		Properties: map[string]string{},
+	}
+}
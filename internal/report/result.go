@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+const (
+	// DefaultSeverity is the severity of results of generic adapters that do not set one
+	DefaultSeverity prv1alpha2.PolicySeverity = "medium"
+	// DefaultResult is the result of results of generic adapters that do not set one
+	DefaultResult prv1alpha2.PolicyResult = "fail"
+)
+
+var (
+	severities = []prv1alpha2.PolicySeverity{"critical", "high", "medium", "low", "info"}
+
+	// PolicyResult has one of the following values:
+	//   - pass: indicates that the policy requirements are met
+	//   - fail: indicates that the policy requirements are not met
+	//   - warn: indicates that the policy requirements and not met, and the policy is not scored
+	//   - error: indicates that the policy could not be evaluated
+	//   - skip: indicates that the policy was not selected based on user inputs or applicability
+	results = []prv1alpha2.PolicyResult{"pass", "fail", "warn", "error", "skip"}
+)
+
+// ParseSeverity returns the severity of the value, or def if the value is empty
+func ParseSeverity(value string, def prv1alpha2.PolicySeverity) (prv1alpha2.PolicySeverity, error) {
+	if value == "" {
+		return def, nil
+	}
+	if s := prv1alpha2.PolicySeverity(value); slices.Contains(severities, s) {
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid severity %q, must be one of %v", value, severities)
+}
+
+// Scored returns if the result is scored, warn and skip results are not
+func Scored(result prv1alpha2.PolicyResult) bool {
+	return result != "warn" && result != "skip"
+}
+
+// ParseResult returns the result of the value, or def if the value is empty
+func ParseResult(value string, def prv1alpha2.PolicyResult) (prv1alpha2.PolicyResult, error) {
+	if value == "" {
+		return def, nil
+	}
+	if r := prv1alpha2.PolicyResult(value); slices.Contains(results, r) {
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid result %q, must be one of %v", value, results)
+}
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"testing"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		value   string
+		def     prv1alpha2.PolicySeverity
+		want    prv1alpha2.PolicySeverity
+		wantErr bool
+	}{
+		{value: "", def: DefaultSeverity, want: "medium"},
+		{value: "", def: "", want: ""},
+		{value: "critical", def: DefaultSeverity, want: "critical"},
+		{value: "info", def: DefaultSeverity, want: "info"},
+		{value: "severe", def: DefaultSeverity, wantErr: true},
+		{value: "High", def: DefaultSeverity, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSeverity(tt.value, tt.def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		value   string
+		def     prv1alpha2.PolicyResult
+		want    prv1alpha2.PolicyResult
+		wantErr bool
+	}{
+		{value: "", def: DefaultResult, want: "fail"},
+		{value: "", def: "", want: ""},
+		{value: "pass", def: DefaultResult, want: "pass"},
+		{value: "skip", def: DefaultResult, want: "skip"},
+		{value: "denied", def: DefaultResult, wantErr: true},
+		{value: "FAIL", def: DefaultResult, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseResult(tt.value, tt.def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResult(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseResult(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScored(t *testing.T) {
+	tests := []struct {
+		result prv1alpha2.PolicyResult
+		want   bool
+	}{
+		{result: "pass", want: true},
+		{result: "fail", want: true},
+		{result: "warn", want: false},
+		{result: "error", want: true},
+		{result: "skip", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.result), func(t *testing.T) {
+			if got := Scored(tt.result); got != tt.want {
+				t.Errorf("Scored(%q) = %v, want %v", tt.result, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakito/policy-report-publisher/internal/env"
+	"github.com/bakito/policy-report-publisher/internal/metrics"
+	"github.com/bakito/policy-report-publisher/version"
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+const labelShardGroup = "policy-report-publisher/shard-group"
+
+// RunSharded runs the pipeline on every replica. Each replica keeps its own membership lease,
+// namespaces are distributed over the live members by consistent (rendezvous) hashing,
+// so adding or removing a replica only moves the namespaces of that replica.
+func (h *handler) RunSharded(ctx context.Context, cancel context.CancelFunc, leaseNamespace string,
+	run func(ctx context.Context, handler Handler, cancel context.CancelFunc),
+) error {
+	cfg, err := leaderElectionConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	// the group is selected by the label of the membership leases
+	if errs := validation.IsValidLabelValue(cfg.leaseName); len(errs) != 0 {
+		return fmt.Errorf("invalid shard group %q in %q: %s", cfg.leaseName, env.LeaderElectionLeaseName,
+			strings.Join(errs, ", "))
+	}
+
+	s := &shards{
+		cfg:    cfg,
+		leases: h.clientset.CoordinationV1().Leases(leaseNamespace),
+		lease:  fmt.Sprintf("%s-%016x", cfg.leaseName, hash64(cfg.identity)),
+		membersGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:      "shard_members",
+			Namespace: metrics.Namespace,
+			Help:      "The number of live replicas the namespaces are sharded across",
+		}),
+	}
+	prometheus.MustRegister(s.membersGauge)
+
+	slog.InfoContext(ctx, "sharding", "namespace", leaseNamespace, "group", cfg.leaseName,
+		"lease", s.lease, "identity", cfg.identity, "lease-duration", cfg.leaseDuration,
+		"renew-deadline", cfg.renewDeadline, "retry-period", cfg.retryPeriod)
+
+	// the first sync must succeed, so events are not dropped because no member is known yet
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	syncCtx, stopSync := context.WithCancel(ctx)
+	wg.Go(func() {
+		wait.UntilWithContext(syncCtx, func(ctx context.Context) {
+			if err := s.sync(ctx); err != nil && syncCtx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to sync shard membership", "error", err)
+			}
+		}, cfg.retryPeriod)
+	})
+
+	run(ctx, &shardedHandler{Handler: h, shards: s}, cancel)
+
+	stopSync()
+	wg.Wait()
+	// leave the group, so the other replicas take over our namespaces right away
+	if err := s.leases.Delete(context.WithoutCancel(ctx), s.lease, metav1.DeleteOptions{}); err != nil &&
+		!errors.IsNotFound(err) {
+		slog.ErrorContext(ctx, "failed to delete shard lease", "lease", s.lease, "error", err)
+	}
+	return nil
+}
+
+// shardedHandler only accepts items of the namespaces owned by this replica
+type shardedHandler struct {
+	Handler
+	shards *shards
+}
+
+func (h *shardedHandler) Responsible(item *Item) bool {
+	return h.shards.owns(item.Namespace)
+}
+
+type shards struct {
+	cfg          *leaderElectionConfig
+	leases       coordinationv1client.LeaseInterface
+	lease        string
+	membersGauge prometheus.Gauge
+
+	mux       sync.RWMutex
+	members   []string
+	lastRenew time.Time
+}
+
+// sync renews the own lease and reads the live members of the group. Expired leases are deleted,
+// they are left behind by replicas that did not shut down gracefully.
+func (s *shards) sync(ctx context.Context) error {
+	now := time.Now()
+	if err := s.renew(ctx, now); err != nil {
+		return err
+	}
+
+	list, err := s.leases.List(ctx, metav1.ListOptions{LabelSelector: labelShardGroup + "=" + s.cfg.leaseName})
+	if err != nil {
+		return err
+	}
+
+	var members []string
+	for _, l := range list.Items {
+		if l.Spec.HolderIdentity == nil || l.Spec.RenewTime == nil || l.Spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		expires := l.Spec.RenewTime.Add(time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second)
+		if now.Before(expires) {
+			members = append(members, *l.Spec.HolderIdentity)
+			continue
+		}
+		// the precondition ensures a lease renewed in the meantime is not deleted
+		err := s.leases.Delete(ctx, l.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &l.ResourceVersion},
+		})
+		if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			slog.ErrorContext(ctx, "failed to delete expired shard lease", "lease", l.Name, "error", err)
+		}
+	}
+	slices.Sort(members)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.lastRenew = now
+	if !slices.Equal(s.members, members) {
+		slog.InfoContext(ctx, "shard members changed, rebalancing namespaces", "members", members)
+		s.members = members
+		s.membersGauge.Set(float64(len(members)))
+	}
+	return nil
+}
+
+func (s *shards) renew(ctx context.Context, renewTime time.Time) error {
+	now := metav1.NewMicroTime(renewTime)
+	identity := s.cfg.identity
+	duration := int32(s.cfg.leaseDuration.Seconds()) // #nosec G115 lease duration is validated
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &identity,
+		LeaseDurationSeconds: &duration,
+		RenewTime:            &now,
+	}
+
+	lease, err := s.leases.Get(ctx, s.lease, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		spec.AcquireTime = &now
+		_, err = s.leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.lease,
+				Labels: map[string]string{
+					"app.kubernetes.io/name": version.Name,
+					labelShardGroup:          s.cfg.leaseName,
+				},
+			},
+			Spec: spec,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	spec.AcquireTime = lease.Spec.AcquireTime
+	lease.Spec = spec
+	_, err = s.leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// owns returns true if this replica is the owner of the namespace. If the own lease could not be renewed
+// within the renew deadline, the other replicas might already have taken over and nothing is owned.
+func (s *shards) owns(namespace string) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if time.Since(s.lastRenew) > s.cfg.renewDeadline {
+		return false
+	}
+
+	var owner string
+	var best uint64
+	for _, m := range s.members {
+		if score := hash64(m + "/" + namespace); owner == "" || score > best {
+			owner, best = m, score
+		}
+	}
+	return owner == s.cfg.identity
+}
+
+func hash64(value string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	return h.Sum64()
+}
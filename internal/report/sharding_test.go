@@ -0,0 +1,87 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHash64(t *testing.T) {
+	tests := []struct {
+		value string
+		want  uint64
+	}{
+		{value: "", want: 0xcbf29ce484222325},
+		{value: "a", want: 0xaf63dc4c8601ec8c},
+		{value: "foobar", want: 0x85944171f73967e8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := hash64(tt.value); got != tt.want {
+				t.Errorf("hash64(%q) = %#x, want %#x", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardsOwns(t *testing.T) {
+	tests := []struct {
+		name      string
+		identity  string
+		members   []string
+		lastRenew time.Duration
+		want      bool
+	}{
+		{name: "single member owns all namespaces", identity: "a", members: []string{"a"}, want: true},
+		{name: "no members", identity: "a", want: false},
+		{name: "not a member", identity: "c", members: []string{"a", "b"}, want: false},
+		{name: "renew deadline exceeded", identity: "a", members: []string{"a"}, lastRenew: time.Minute, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestShards(tt.identity, tt.members...)
+			s.lastRenew = time.Now().Add(-tt.lastRenew)
+			if got := s.owns("default"); got != tt.want {
+				t.Errorf("owns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardsOwnsRebalance(t *testing.T) {
+	members := []string{"a", "b", "c"}
+	owners := func(members ...string) map[string]string {
+		owner := map[string]string{}
+		for i := range 100 {
+			ns := fmt.Sprintf("ns-%d", i)
+			for _, m := range members {
+				if newTestShards(m, members...).owns(ns) {
+					if owner[ns] != "" {
+						t.Fatalf("namespace %q is owned by %q and %q", ns, owner[ns], m)
+					}
+					owner[ns] = m
+				}
+			}
+			if owner[ns] == "" {
+				t.Fatalf("namespace %q has no owner", ns)
+			}
+		}
+		return owner
+	}
+
+	before := owners(members...)
+	after := owners("a", "b")
+	for ns, owner := range before {
+		if owner != "c" && after[ns] != owner {
+			t.Errorf("namespace %q moved from %q to %q, only the namespaces of the removed member must move", ns, owner, after[ns])
+		}
+	}
+}
+
+func newTestShards(identity string, members ...string) *shards {
+	return &shards{
+		cfg:       &leaderElectionConfig{identity: identity, renewDeadline: defaultRenewDeadline},
+		members:   members,
+		lastRenew: time.Now(),
+	}
+}
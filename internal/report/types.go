@@ -15,6 +15,9 @@ type Handler interface {
 	Update(ctx context.Context, item *Item) error
 	PolicyReportAvailable() (bool, error)
 	RunAsLeader(ctx context.Context, cancel context.CancelFunc, leaseLockNamespace string, run func(ctx context.Context, handler Handler, cancel context.CancelFunc)) error
+	RunSharded(ctx context.Context, cancel context.CancelFunc, leaseNamespace string, run func(ctx context.Context, handler Handler, cancel context.CancelFunc)) error
+	// Responsible returns false if the item has to be handled by another replica
+	Responsible(item *Item) bool
 }
 
 type handler struct {
@@ -23,13 +26,28 @@ type handler struct {
 	logReports bool
 	clientset  clientset.Interface
 	counter    *prometheus.CounterVec
+	// nodeName restricts the updates of the items observed on a node to this node
+	nodeName string
+	// cluster is the name of the remote cluster of the handler, empty for the local cluster
+	cluster string
+	// remotes are the handlers of the remote clusters by name
+	remotes map[string]*handler
+	// publisher is the name of the publisher install, empty if there is a single install
+	publisher string
 }
 
 type Item struct {
 	client.ObjectKey
-	handlerID string
-	result    prv1alpha2.PolicyReportResult
-	source    any
+	handlerID       string
+	result          prv1alpha2.PolicyReportResult
+	source          any
+	namespaceScoped bool
+	// cluster is the remote cluster of the item, empty for the local cluster
+	cluster string
+	// node is the node the item was observed on, empty if unknown
+	node string
+	// instance is the instance of the adapter the item was received from, empty for a single instance
+	instance string
 }
 
 func ItemFor(handlerID string, namespace string, name string, result prv1alpha2.PolicyReportResult, source any) *Item {
@@ -43,3 +61,55 @@ func ItemFor(handlerID string, namespace string, name string, result prv1alpha2.
 		source: source,
 	}
 }
+
+// NamespaceItemFor creates an item for the namespace level report, for results not related to a pod
+func NamespaceItemFor(handlerID string, namespace string, result prv1alpha2.PolicyReportResult, source any) *Item {
+	return &Item{
+		handlerID: handlerID,
+		ObjectKey: types.NamespacedName{
+			Namespace: namespace,
+		},
+		result:          result,
+		source:          source,
+		namespaceScoped: true,
+	}
+}
+
+// InCluster sets the remote cluster the item is written to
+func (i *Item) InCluster(cluster string) *Item {
+	i.cluster = cluster
+	return i
+}
+
+// OnNode sets the node the item was observed on, in node local mode the item is only written by the publisher
+// of this node
+func (i *Item) OnNode(node string) *Item {
+	i.node = node
+	return i
+}
+
+// ForInstance sets the instance of the adapter the item was received from
+func (i *Item) ForInstance(instance string) *Item {
+	i.instance = instance
+	return i
+}
+
+// HandlerID returns the id of the adapter the item was created by
+func (i *Item) HandlerID() string {
+	return i.handlerID
+}
+
+// Instance returns the instance of the adapter the item was received from
+func (i *Item) Instance() string {
+	return i.instance
+}
+
+// Result returns the result of the item
+func (i *Item) Result() prv1alpha2.PolicyReportResult {
+	return i.result
+}
+
+// NamespaceScoped returns true if the item is written to the namespace level report
+func (i *Item) NamespaceScoped() bool {
+	return i.namespaceScoped
+}
@@ -0,0 +1,93 @@
+package suggest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	prv1alpha2 "github.com/kyverno/kyverno/api/policyreport/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Command is the name of the command that prints the suggested policy of a workload
+const Command = "suggest-policy"
+
+// Run prints a policy that allows the suggested egress rules of the current results of the pods of a workload
+func Run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "the namespace of the workload")
+	selector := fs.String("selector", "", "the label selector of the pods of the workload, e.g. app=backend")
+	kind := fs.String("kind", KindCilium, fmt.Sprintf("the kind of the policy: %s or %s", KindCilium, KindKubernetes))
+	name := fs.String("name", "suggested-egress", "the name of the policy")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *namespace == "" || *selector == "" {
+		return errors.New("namespace and selector must be set")
+	}
+	// the selector of the policy must be equality based
+	podLabels, err := labels.ConvertSelectorToLabelsMap(*selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %w", *selector, err)
+	}
+
+	cl, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	if err := cl.List(ctx, pods, client.InNamespace(*namespace), client.MatchingLabels(podLabels)); err != nil {
+		return err
+	}
+	var names []string
+	for _, p := range pods.Items {
+		names = append(names, p.Name)
+	}
+
+	reports := &prv1alpha2.PolicyReportList{}
+	if err := cl.List(ctx, reports, client.InNamespace(*namespace)); err != nil {
+		return err
+	}
+	var rules []string
+	for _, r := range reports.Items {
+		if r.Scope == nil || r.Scope.Kind != "Pod" || !slices.Contains(names, r.Scope.Name) {
+			continue
+		}
+		for _, res := range r.Results {
+			if res.Properties[PropertyPolicyKind] == *kind && res.Properties[PropertyPolicy] != "" {
+				rules = append(rules, res.Properties[PropertyPolicy])
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no suggested %s rules found for the %d pods of %q", *kind, len(names), *selector)
+	}
+
+	policy, err := Policy(*kind, Metadata{Name: *name, Namespace: *namespace}, podLabels, rules)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(policy)
+	return err
+}
+
+func newClient() (client.Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(prv1alpha2.Install(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	config, err := (&genericclioptions.ConfigFlags{}).ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
@@ -0,0 +1,88 @@
+package suggest
+
+import (
+	"fmt"
+	"slices"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// dnsRule allows the queries to kube-dns, toFQDNs rules only match names resolved by the DNS proxy
+var dnsRule = EgressRule{
+	ToEndpoints: []EndpointSelector{{MatchLabels: map[string]string{
+		"io.kubernetes.pod.namespace": "kube-system",
+		"k8s-app":                     "kube-dns",
+	}}},
+	ToPorts: []PortRule{{
+		Ports: []PortProtocol{{Port: "53", Protocol: "ANY"}},
+		Rules: &L7Rules{DNS: []FQDNSelector{{MatchPattern: "*"}}},
+	}},
+}
+
+// Rule returns the YAML of a suggested egress rule: an EgressRule or a NetworkPolicyEgressRule
+func Rule(rule any) (string, error) {
+	b, err := yaml.Marshal(rule)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Policy returns the YAML of a policy of the kind that allows the suggested egress rules
+func Policy(kind string, meta Metadata, podLabels map[string]string, rules []string) ([]byte, error) {
+	switch kind {
+	case KindCilium:
+		egress, err := parse[EgressRule](rules)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(egress, func(r EgressRule) bool { return len(r.ToFQDNs) > 0 }) {
+			egress = append([]EgressRule{dnsRule}, egress...)
+		}
+		return yaml.Marshal(CiliumNetworkPolicy{
+			APIVersion: "cilium.io/v2",
+			Kind:       "CiliumNetworkPolicy",
+			Metadata:   meta,
+			Spec: CiliumPolicySpec{
+				EndpointSelector: EndpointSelector{MatchLabels: podLabels},
+				Egress:           egress,
+			},
+		})
+	case KindKubernetes:
+		egress, err := parse[networkingv1.NetworkPolicyEgressRule](rules)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(networkingv1.NetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: meta.Name, Namespace: meta.Namespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      egress,
+			},
+		})
+	default:
+		return nil, fmt.Errorf("unknown policy kind %q, must be one of %q, %q", kind, KindCilium, KindKubernetes)
+	}
+}
+
+// parse returns the distinct rules
+func parse[T any](rules []string) ([]T, error) {
+	var parsed []T
+	seen := map[string]bool{}
+	for _, r := range rules {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		var rule T
+		if err := yaml.UnmarshalStrict([]byte(r), &rule); err != nil {
+			return nil, fmt.Errorf("invalid suggested rule: %w", err)
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
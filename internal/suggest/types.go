@@ -0,0 +1,100 @@
+package suggest
+
+const (
+	// PropertyPolicy is the property of a result with the suggested egress rule as YAML
+	PropertyPolicy = "suggested-policy"
+	// PropertyPolicyKind is the property of a result with the kind of the suggested egress rule
+	PropertyPolicyKind = "suggested-policy-kind"
+
+	// KindCilium suggests CiliumNetworkPolicy egress rules
+	KindCilium = "cilium"
+	// KindKubernetes suggests Kubernetes NetworkPolicy egress rules
+	KindKubernetes = "kubernetes"
+)
+
+// The types are the subset of the CiliumNetworkPolicy used by the suggested rules, the cilium policy package is not used
+// as it depends on most of the cilium agent.
+
+// CiliumNetworkPolicy is a cilium.io/v2 CiliumNetworkPolicy
+type CiliumNetworkPolicy struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Metadata   Metadata         `json:"metadata"`
+	Spec       CiliumPolicySpec `json:"spec"`
+}
+
+// Metadata is the metadata of a suggested policy
+type Metadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// CiliumPolicySpec is the spec of a CiliumNetworkPolicy
+type CiliumPolicySpec struct {
+	EndpointSelector EndpointSelector `json:"endpointSelector"`
+	Egress           []EgressRule     `json:"egress"`
+}
+
+// EndpointSelector selects endpoints by their labels
+type EndpointSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// EgressRule is an egress rule of a CiliumNetworkPolicy
+type EgressRule struct {
+	ToEndpoints []EndpointSelector `json:"toEndpoints,omitempty"`
+	ToEntities  []string           `json:"toEntities,omitempty"`
+	ToCIDR      []string           `json:"toCIDR,omitempty"`
+	ToFQDNs     []FQDNSelector     `json:"toFQDNs,omitempty"`
+	ToPorts     []PortRule         `json:"toPorts,omitempty"`
+	ICMPs       []ICMPRule         `json:"icmps,omitempty"`
+}
+
+// FQDNSelector selects a name or a pattern of names
+type FQDNSelector struct {
+	MatchName    string `json:"matchName,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+// PortRule allows ports with optional L7 rules
+type PortRule struct {
+	Ports []PortProtocol `json:"ports"`
+	Rules *L7Rules       `json:"rules,omitempty"`
+}
+
+// PortProtocol is a port and its protocol
+type PortProtocol struct {
+	Port     string `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// L7Rules are the L7 rules of a port
+type L7Rules struct {
+	HTTP  []HTTPRule     `json:"http,omitempty"`
+	Kafka []KafkaRule    `json:"kafka,omitempty"`
+	DNS   []FQDNSelector `json:"dns,omitempty"`
+}
+
+// HTTPRule allows HTTP requests, the path is a regular expression
+type HTTPRule struct {
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Host   string `json:"host,omitempty"`
+}
+
+// KafkaRule allows Kafka requests
+type KafkaRule struct {
+	APIKey string `json:"apiKey,omitempty"`
+	Topic  string `json:"topic,omitempty"`
+}
+
+// ICMPRule allows ICMP messages
+type ICMPRule struct {
+	Fields []ICMPField `json:"fields"`
+}
+
+// ICMPField is an ICMP message type
+type ICMPField struct {
+	Family string `json:"family,omitempty"`
+	Type   uint32 `json:"type"`
+}
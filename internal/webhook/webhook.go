@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// MaxBodySize is the maximum size of a request body accepted by the webhook adapters
+const MaxBodySize = 4 << 20
+
+// TLS are the files to serve a webhook with TLS, the webhook is served without TLS if no certificate is set
+type TLS struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the CA the required client certificates are verified with, client certificates are not required if empty
+	ClientCAFile string
+}
+
+// Serve runs an HTTP server for a webhook adapter until ctx is done.
+// It only returns once all active requests are completed, so handlers can safely send to the report channel
+// as long as they stop sending when ctx is done.
+func Serve(ctx context.Context, name string, address string, handler http.Handler) error {
+	return ServeTLS(ctx, name, address, TLS{}, handler)
+}
+
+// ServeTLS is like Serve, but serves HTTPS if the cert and key files are set
+func ServeTLS(ctx context.Context, name string, address string, t TLS, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+	}
+	if t.ClientCAFile != "" {
+		if t.CertFile == "" {
+			return fmt.Errorf("the client CA of the %s webhook requires a server certificate", name)
+		}
+		pem, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA %q", t.ClientCAFile)
+		}
+		server.TLSConfig = &tls.Config{
+			ClientCAs:  pool,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		slog.InfoContext(ctx, "starting webhook", "name", name, "address", address, "tls", t.CertFile != "",
+			"client-ca", t.ClientCAFile != "")
+		var err error
+		if t.CertFile != "" || t.KeyFile != "" {
+			err = server.ListenAndServeTLS(t.CertFile, t.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errChan <- err
+		}
+		close(errChan)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return server.Shutdown(context.WithoutCancel(ctx))
+	}
+}
+
+// Authorize returns a handler that rejects the requests that do not send the token as `Authorization: Bearer <token>`.
+// If the token is empty, the handler is returned as is.
+func Authorize(token string, handler http.Handler) http.Handler {
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "no token", want: http.StatusOK},
+		{name: "no token ignores the header", authorization: "Bearer other", want: http.StatusOK},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", want: http.StatusOK},
+		{name: "missing header", token: "secret", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authorization: "Bearer other", want: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "secret", authorization: "Basic secret", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Authorize(tt.token, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
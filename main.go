@@ -2,38 +2,115 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/bakito/policy-report-publisher/internal/adapter/audit"
+	"github.com/bakito/policy-report-publisher/internal/adapter/envoy"
+	"github.com/bakito/policy-report-publisher/internal/adapter/events"
+	"github.com/bakito/policy-report-publisher/internal/adapter/external"
+	"github.com/bakito/policy-report-publisher/internal/adapter/falco"
 	"github.com/bakito/policy-report-publisher/internal/adapter/hubble"
+	"github.com/bakito/policy-report-publisher/internal/adapter/ingest"
 	"github.com/bakito/policy-report-publisher/internal/adapter/kubearmor"
+	"github.com/bakito/policy-report-publisher/internal/adapter/tetragon"
 	"github.com/bakito/policy-report-publisher/internal/env"
 	"github.com/bakito/policy-report-publisher/internal/metrics"
+	"github.com/bakito/policy-report-publisher/internal/pipeline"
 	"github.com/bakito/policy-report-publisher/internal/report"
+	"github.com/bakito/policy-report-publisher/internal/suggest"
 	"github.com/bakito/policy-report-publisher/version"
 	"k8s.io/klog/v2"
 )
 
+const (
+	defaultShutdownTimeout = 10 * time.Second
+
+	// haModeLeader runs the adapters on the leader only
+	haModeLeader = "leader"
+	// haModeSharded runs the adapters on all replicas, each handling its shard of namespaces
+	haModeSharded = "sharded"
+)
+
+// adapters are enabled if their service variable is set. The items of push adapters are sent to them
+// (e.g. by a webhook behind a Service), they are not filtered by the namespaces of the shard.
+var adapters = []struct {
+	name       string
+	serviceVar string
+	run        pipeline.RunFunc
+	push       bool
+}{
+	{name: "KubeArmor", serviceVar: env.KubeArmorServiceName, run: kubearmor.Run},
+	{name: "Hubble", serviceVar: env.HubbleServiceName, run: hubble.Run},
+	{name: "Falco", serviceVar: env.FalcoWebhookAddress, run: falco.Run, push: true},
+	{name: "Tetragon", serviceVar: env.TetragonServiceName, run: tetragon.Run},
+	{name: "Audit", serviceVar: env.AuditWebhookAddress, run: audit.Run, push: true},
+	{name: "Events", serviceVar: env.KubeEventsReasons, run: events.Run},
+	{name: "Ingest", serviceVar: env.IngestWebhookAddress, run: ingest.Run, push: true},
+	{name: "Envoy", serviceVar: env.EnvoyALSAddress, run: envoy.Run, push: true},
+	{name: "External", serviceVar: env.ExternalAdapterAddress, run: external.Run, push: true},
+}
+
 func main() {
 	ctx := context.Background()
+	if len(os.Args) > 1 && os.Args[1] == suggest.Command {
+		if err := suggest.Run(ctx, os.Args[2:], os.Stdout); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 	klog.SetSlogLogger(logger)
 
-	if env.Empty(env.HubbleServiceName) && env.Empty(env.KubeArmorServiceName) {
-		slog.ErrorContext(ctx, "either 'Hubble' or 'KubeArmor' must be enabled",
-			"hubble", env.HubbleServiceName,
-			"kubearmor", env.KubeArmorServiceName)
+	var enabled []any
+	var variables []string
+	for _, a := range adapters {
+		variables = append(variables, a.serviceVar)
+		if !env.Empty(a.serviceVar) {
+			enabled = append(enabled, strings.ToLower(a.name), os.Getenv(a.serviceVar))
+		}
+	}
+	if len(enabled) == 0 {
+		slog.ErrorContext(ctx, "at least one adapter must be enabled", "variables", variables)
+		os.Exit(1)
+	}
+
+	if env.Active(env.KubeArmorNodeLocal) && !env.Empty(env.LeaderElectionNS) {
+		// in node local mode every DaemonSet pod handles the pods of its node
+		slog.ErrorContext(ctx, "node local mode can not be combined with leader election",
+			"node-local", env.KubeArmorNodeLocal,
+			"leader-election", env.LeaderElectionNS)
+		os.Exit(1)
+	}
+
+	shutdownTimeout, err := env.Duration(env.ShutdownTimeout, defaultShutdownTimeout)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid shutdown timeout", "error", err)
 		os.Exit(1)
 	}
 
 	slog.InfoContext(ctx, "policy-report-publisher", "version", version.Version,
-		"hubble", os.Getenv(env.HubbleServiceName),
-		"kubearmor", os.Getenv(env.KubeArmorServiceName),
-		"log-reports", env.Active(env.LogReports))
+		slog.Group("adapters", enabled...),
+		"publisher", os.Getenv(env.PublisherName),
+		"log-reports", env.Active(env.LogReports),
+		"shutdown-timeout", shutdownTimeout,
+		"ha-mode", os.Getenv(env.HAMode),
+		"node-local", env.Active(env.KubeArmorNodeLocal))
+
+	publisher, err := report.Publisher()
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid publisher name", "error", err)
+		os.Exit(1)
+	}
+	metrics.WithPublisher(publisher)
 
 	// Initialize the report handler
 	handler, err := report.NewHandler()
@@ -62,65 +139,45 @@ func main() {
 
 	go metrics.Start(ctx)
 
-	if ns, ok := os.LookupEnv(env.LeaderElectionNS); ok && strings.TrimSpace(ns) != "" {
-		if err := handler.RunAsLeader(ctx, cancel, ns, run); err != nil {
-			slog.ErrorContext(ctx, "error running with leader election", "error", err)
-			os.Exit(1)
+	// Handle OS signals for graceful shutdown: the signal stops the adapters first,
+	// ctx (and with it the lease) is only cancelled once the queued reports are flushed.
+	stopCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	p, err := pipeline.New(stopCtx, shutdownTimeout)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid pipeline configuration", "error", err)
+		os.Exit(1)
+	}
+	for _, a := range adapters {
+		if a.push {
+			p.AddPush(a.name, a.serviceVar, a.run)
+		} else {
+			p.Add(a.name, a.serviceVar, a.run)
 		}
-	} else {
-		run(ctx, handler, cancel)
 	}
-}
-
-func run(ctx context.Context, handler report.Handler, cancel context.CancelFunc) {
-	// Create a channel for reports
-	reportChan := make(chan *report.Item, 100) // Buffered for performance
 
-	// Handle OS signals for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		<-sigChan
+		<-stopCtx.Done()
 		slog.InfoContext(ctx, "Shutting down gracefully...")
+		p.Wait()
 		cancel()
 	}()
 
-	start(ctx, reportChan, cancel, "KubeArmor", env.KubeArmorServiceName, kubearmor.Run)
-	start(ctx, reportChan, cancel, "Hubble", env.HubbleServiceName, hubble.Run)
-
-	// Process reports from the channel
-	for {
-		select {
-		case rep, ok := <-reportChan:
-			if !ok {
-				// Channel closed, exit loop
-				return
-			}
-			if err := handler.Update(ctx, rep); err != nil {
-				slog.ErrorContext(ctx, "Failed to update report", "error", err)
-			}
-		case <-ctx.Done():
-			// Context is done, exit loop
-			slog.InfoContext(ctx, "Context done, exiting report processing loop.")
-			return
+	if ns, ok := os.LookupEnv(env.LeaderElectionNS); ok && strings.TrimSpace(ns) != "" {
+		switch mode := env.String(env.HAMode, haModeLeader); mode {
+		case haModeLeader:
+			err = handler.RunAsLeader(ctx, cancel, ns, p.Run)
+		case haModeSharded:
+			err = handler.RunSharded(ctx, cancel, ns, p.Run)
+		default:
+			err = fmt.Errorf("unknown HA mode %q, must be one of %q, %q", mode, haModeLeader, haModeSharded)
 		}
-	}
-}
-
-func start(ctx context.Context,
-	reportChan chan *report.Item,
-	cancel context.CancelFunc,
-	name string,
-	serviceVar string,
-	run func(ctx context.Context, reportChan chan *report.Item) error,
-) {
-	if !env.Empty(serviceVar) {
-		go func() {
-			slog.InfoContext(ctx, "starting", "name", name, "service", os.Getenv(serviceVar))
-			if err := run(ctx, reportChan); err != nil {
-				slog.ErrorContext(ctx, "run exited with error", "name", name, "error", err)
-				cancel()
-			}
-		}()
+		if err != nil {
+			slog.ErrorContext(ctx, "error running with leader election", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		p.Run(ctx, handler, cancel)
 	}
 }